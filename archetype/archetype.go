@@ -0,0 +1,128 @@
+// Package archetype creates new content files from templates.
+//
+// An archetype is a text/template stored at archetypes/<kind>.md.
+// When none exists for "post" a built-in default is used instead.
+package archetype
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/aedipamoss/stationery/config"
+	"github.com/aedipamoss/stationery/page"
+)
+
+// Dir is the directory archetypes are read from.
+const Dir string = "archetypes"
+
+// DefaultPost is used for posts when archetypes/post.md doesn't exist.
+const DefaultPost = `---
+title: {{ yaml .Title }}
+timestamp: {{ .Timestamp }}
+tags: []
+draft: true
+---
+
+`
+
+// Data is made available to archetype templates.
+type Data struct {
+	Kind      string
+	Title     string
+	Slug      string
+	Timestamp string    // Date formatted with time.RFC3339
+	Date      time.Time // creation time in the site time zone
+}
+
+// Quote a value so it's safe to use as a YAML scalar in front-matter.
+func quote(value string) (string, error) {
+	out, err := yaml.Marshal(value)
+	return strings.TrimSpace(string(out)), err
+}
+
+func load(kind string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(Dir, kind+".md"))
+	if err == nil {
+		return string(content), nil
+	}
+
+	if os.IsNotExist(err) && kind == "post" {
+		return DefaultPost, nil
+	}
+
+	return "", err
+}
+
+// Render executes the archetype for kind with the given data.
+func Render(kind string, data Data) ([]byte, error) {
+	src, err := load(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	tpl, err := template.New(kind).Funcs(template.FuncMap{"yaml": quote}).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	err = tpl.Execute(buf, data)
+	return buf.Bytes(), err
+}
+
+// New writes a new file of the given kind into the configured source directory.
+// The file name is the slugified title and existing files are never overwritten.
+// It returns the path of the file written.
+func New(cfg config.Config, kind string, title string, now time.Time) (string, error) {
+	slug := page.Slugify(title)
+	if slug == "" {
+		return "", fmt.Errorf("unable to make a file name from title %q", title)
+	}
+
+	source, err := os.Stat(cfg.Source)
+	if err != nil {
+		return "", err
+	}
+	if !source.IsDir() {
+		return "", fmt.Errorf("source %s is a single file, not a directory", cfg.Source)
+	}
+
+	loc, err := cfg.Location()
+	if err != nil {
+		return "", err
+	}
+	now = now.In(loc)
+
+	content, err := Render(kind, Data{
+		Kind:      kind,
+		Title:     title,
+		Slug:      slug,
+		Timestamp: now.Format(time.RFC3339),
+		Date:      now,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	dest := filepath.Join(cfg.Source, slug+".md")
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(content)
+	if err != nil {
+		f.Close() // nolint: errcheck
+		return "", err
+	}
+
+	return dest, f.Close()
+}
@@ -7,6 +7,9 @@ import (
 	"github.com/aedipamoss/stationery/fileutils"
 )
 
+// Dir is the directory assets are copied from.
+const Dir string = "assets"
+
 // List is a struct containing all the CSS, JavaScript, and Images to be built.
 type List struct {
 	CSS    []string
@@ -15,7 +18,7 @@ type List struct {
 }
 
 func setupAndCopy(files []string, src string, dest string) error {
-	from := filepath.Join("assets", src)
+	from := filepath.Join(Dir, src)
 	to := filepath.Join(dest, src)
 
 	err := os.MkdirAll(to, 0700)
@@ -26,6 +29,35 @@ func setupAndCopy(files []string, src string, dest string) error {
 	return fileutils.CopyFiles(files, from, to)
 }
 
+// Paths returns the path on disk of every asset in the list.
+func (assets *List) Paths() []string {
+	var paths []string
+	for _, css := range assets.CSS {
+		paths = append(paths, filepath.Join(Dir, "css", css))
+	}
+	for _, js := range assets.JS {
+		paths = append(paths, filepath.Join(Dir, "js", js))
+	}
+	for _, image := range assets.Images {
+		paths = append(paths, filepath.Join(Dir, "images", image))
+	}
+
+	return paths
+}
+
+// Destinations returns the path every asset is copied to by Generate, under dest.
+func (assets *List) Destinations(dest string) []string {
+	var paths []string
+	for _, css := range assets.CSS {
+		paths = append(paths, filepath.Join(dest, "css", css))
+	}
+	for _, image := range assets.Images {
+		paths = append(paths, filepath.Join(dest, "images", image))
+	}
+
+	return paths
+}
+
 // Generate will copy assets from each field, CSS, Images, and JS.
 // It copies each file listed to the provided destination.
 func (assets *List) Generate(dest string) error {
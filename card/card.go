@@ -0,0 +1,165 @@
+// Package card renders the social card image of a page, shown when the page is shared.
+//
+// Only the standard image packages are used, text is drawn with an embedded bitmap font
+// scaled up, so it's limited to ASCII and other characters are drawn as '?'.
+package card
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Size of a card in pixels, the ratio expected by Twitter and OpenGraph large images.
+const (
+	Width  = 1200
+	Height = 630
+)
+
+const (
+	margin     = 80
+	smallScale = 4 // scale of the site name and the date
+	titleScale = 8
+	titleLines = 4
+)
+
+var (
+	// Background is used behind the text when a card has no background image.
+	Background = color.RGBA{0x22, 0x27, 0x2e, 0xff}
+	// Foreground is the color of the text.
+	Foreground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	// darkens background images so the text stays readable
+	shade = color.RGBA{0x00, 0x00, 0x00, 0xa0}
+)
+
+// Card is what's drawn on the image of a page.
+type Card struct {
+	Title      string
+	Site       string      // name of the site, above the title
+	Date       string      // below the title, left out when empty
+	Background image.Image // optional, scaled to cover the whole card
+}
+
+// Draw text at x, y with each pixel of the font scaled up to a square of scale pixels.
+func drawText(img draw.Image, text string, x int, y int, scale int) {
+	fg := image.NewUniform(Foreground)
+	for _, r := range text {
+		g := glyph(r)
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if g[row]&(0x10>>uint(col)) == 0 {
+					continue
+				}
+				px := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+				draw.Draw(img, px, fg, image.ZP, draw.Src)
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
+
+// Returns how many characters at scale fit between the margins.
+func lineLength(scale int) int {
+	return (Width - 2*margin) / ((glyphWidth + 1) * scale)
+}
+
+// Wrap text into at most max lines of length characters,
+// breaking words longer than a line and ending with "..." when it doesn't fit.
+func wrap(text string, length int, max int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for len([]rune(word)) > length {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string([]rune(word)[:length]))
+			word = string([]rune(word)[length:])
+		}
+
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= length:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	if len(lines) > max {
+		lines = lines[:max]
+		last := []rune(lines[max-1])
+		if len(last) > length-3 {
+			last = last[:length-3]
+		}
+		lines[max-1] = strings.TrimRight(string(last), " ") + "..."
+	}
+
+	return lines
+}
+
+// Scale src to cover dst, cropping what's left over from the center.
+// Pixels are picked from the nearest neighbour, which is enough behind a shade.
+func cover(dst *image.RGBA, src image.Image) {
+	b := src.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return
+	}
+
+	// the smaller ratio of source to card pixels covers the card in both directions
+	ratio := float64(b.Dx()) / Width
+	if r := float64(b.Dy()) / Height; r < ratio {
+		ratio = r
+	}
+	offsetX := (float64(b.Dx()) - Width*ratio) / 2
+	offsetY := (float64(b.Dy()) - Height*ratio) / 2
+
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			sx := b.Min.X + int(offsetX+float64(x)*ratio)
+			sy := b.Min.Y + int(offsetY+float64(y)*ratio)
+			dst.Set(x, y, src.At(sx, sy))
+		}
+	}
+}
+
+// Render draws the card.
+func (c Card) Render() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	if c.Background != nil {
+		cover(img, c.Background)
+		draw.Draw(img, img.Bounds(), image.NewUniform(shade), image.ZP, draw.Over)
+	} else {
+		draw.Draw(img, img.Bounds(), image.NewUniform(Background), image.ZP, draw.Src)
+	}
+
+	for _, line := range wrap(c.Site, lineLength(smallScale), 1) {
+		drawText(img, line, margin, margin, smallScale)
+	}
+
+	y := margin + 110
+	for _, line := range wrap(c.Title, lineLength(titleScale), titleLines) {
+		drawText(img, line, margin, y, titleScale)
+		y += (glyphHeight + 3) * titleScale
+	}
+
+	if c.Date != "" {
+		drawText(img, c.Date, margin, Height-margin-glyphHeight*smallScale, smallScale)
+	}
+
+	return img
+}
+
+// Encode writes the card as a PNG image to w.
+func (c Card) Encode(w io.Writer) error {
+	return png.Encode(w, c.Render())
+}
@@ -0,0 +1,50 @@
+package card
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		text     string
+		length   int
+		max      int
+		expected []string
+	}{
+		{"zomg is a thing", 10, 2, []string{"zomg is a", "thing"}},
+		{"a verylongword", 5, 3, []string{"a", "veryl", "on..."}},
+		{"one two three four", 7, 2, []string{"one two", "thre..."}},
+		{"", 10, 2, nil},
+	}
+
+	for _, test := range tests {
+		if got := wrap(test.text, test.length, test.max); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("wrap(%q, %d, %d) = %q, expected %q", test.text, test.length, test.max, got, test.expected)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	img := Card{Title: "zomg", Site: "my blog", Date: "Mar 24, 2018"}.Render()
+	if img.Bounds() != image.Rect(0, 0, Width, Height) {
+		t.Fatalf("expected a %dx%d card, got %v", Width, Height, img.Bounds())
+	}
+
+	if got := img.RGBAAt(0, 0); got != Background {
+		t.Errorf("expected the background color in the corner, got %v", got)
+	}
+
+	// the top-left pixel of the 'z' of the title
+	if got := img.RGBAAt(margin, margin+110+2*titleScale); got != Foreground {
+		t.Errorf("expected the title to be drawn, got %v", got)
+	}
+
+	red := image.NewUniform(color.RGBA{0xff, 0x00, 0x00, 0xff})
+	img = Card{Title: "zomg", Background: red}.Render()
+	if got := img.RGBAAt(0, 0); got.R == 0 || got.G != 0 || got.R == 0xff {
+		t.Errorf("expected a shaded red background, got %v", got)
+	}
+}
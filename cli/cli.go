@@ -0,0 +1,232 @@
+// Package cli implements the stationery command line.
+//
+// Usage:
+//
+//	stationery [global flags] <command> [flags] [arguments]
+//
+// Global flags may also be given after the command name.
+// When no command is given the site is built, just like `stationery build`.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aedipamoss/stationery/config"
+	"github.com/aedipamoss/stationery/logger"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK    = 0 // the command succeeded
+	ExitError = 1 // the command failed
+	ExitUsage = 2 // the command line was invalid
+)
+
+// Version is the version of stationery, set at build time with:
+//
+//	-ldflags "-X github.com/aedipamoss/stationery/cli.Version=..."
+var Version = "dev"
+
+// Options are the global flags shared by every command.
+type Options struct {
+	Config  string // path to the configuration file
+	Env     string // profile merged over the configuration, like "production"
+	Source  string // overrides config.Config.Source when set
+	Output  string // overrides config.Config.Output when set
+	Quiet   bool
+	Verbose bool
+
+	stderr io.Writer
+}
+
+// A command is a single subcommand like "build" or "new".
+type command struct {
+	usage   string // arguments shown in the help after the name
+	summary string // one line description shown in the help
+	run     func(opts *Options, args []string) error
+}
+
+var commands map[string]command
+
+// Commands are registered in init to avoid an initialization loop with help.
+func init() {
+	commands = map[string]command{
+		"build":   {"[-preview] [-drafts]", "Generate the site into the output directory", build},
+		"serve":   {"[-addr host:port] [-drafts]", "Generate the site and serve it over HTTP", serve},
+		"check":   {"", "Load the configuration and every page without writing anything", check},
+		"clean":   {"", "Remove the output directory", clean},
+		"init":    {"[-y] [flags] [dir]", "Create a new project", initProject},
+		"new":     {"<kind> <title>", "Create a new page from an archetype", newContent},
+		"publish": {"[-rename] <file>", "Mark a draft as published", publish},
+		"version": {"", "Print the version of stationery", version},
+		"help":    {"", "Print this help", help},
+	}
+}
+
+// usageError is returned when the command line is invalid.
+type usageError struct {
+	msg   string
+	shown bool // the flag package already printed the error and usage
+}
+
+func (err usageError) Error() string {
+	return err.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// Register the global flags on a flag set, keeping any value already parsed.
+func (opts *Options) register(flags *flag.FlagSet) {
+	flags.StringVar(&opts.Config, "config", opts.Config, "Path to the configuration file")
+	flags.StringVar(&opts.Env, "env", opts.Env, "Merge the profile for this environment over the configuration")
+	flags.StringVar(&opts.Source, "source", opts.Source, "Override the source from the configuration")
+	flags.StringVar(&opts.Output, "output", opts.Output, "Override the output from the configuration")
+	flags.BoolVar(&opts.Quiet, "quiet", opts.Quiet, "Only print errors")
+	flags.BoolVar(&opts.Verbose, "verbose", opts.Verbose, "Print more details")
+}
+
+// flags returns a flag set for the named command with the global flags already registered.
+func (opts *Options) flags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(opts.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(opts.stderr, "Usage: stationery %s %s\n", name, commands[name].usage)
+		flags.PrintDefaults()
+	}
+	opts.register(flags)
+	return flags
+}
+
+// parse the flags of a command, turning any failure into a usageError.
+func (opts *Options) parse(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err == flag.ErrHelp {
+		return err
+	}
+	if err != nil {
+		return usageError{msg: err.Error(), shown: true}
+	}
+
+	opts.setLevel()
+	return nil
+}
+
+func (opts *Options) setLevel() {
+	switch {
+	case opts.Quiet:
+		logger.Current = logger.Quiet
+	case opts.Verbose:
+		logger.Current = logger.Verbose
+	default:
+		logger.Current = logger.Normal
+	}
+}
+
+// loadConfig reads the configuration and applies any overrides from flags.
+func (opts *Options) loadConfig() (config.Config, error) {
+	logger.Debug("Loading configuration: %s", opts.Config)
+	if opts.Env != "" {
+		logger.Debug("Loading profile: %s", config.ProfileFile(opts.Config, opts.Env))
+	}
+	cfg, err := config.LoadFile(opts.Config, opts.Env)
+	if err != nil {
+		return cfg, err
+	}
+
+	if opts.Source != "" {
+		cfg.Source = opts.Source
+	}
+	if opts.Output != "" {
+		cfg.Output = opts.Output
+	}
+
+	return cfg, nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: stationery [global flags] <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-8s %s\n", name, commands[name].summary)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Global flags:")
+	flags := flag.NewFlagSet("stationery", flag.ContinueOnError)
+	flags.SetOutput(w)
+	(&Options{Config: config.ConfigFile}).register(flags)
+	flags.PrintDefaults()
+}
+
+// Run parses the command line arguments, without the program name,
+// runs the requested command and returns the exit code.
+func Run(args []string) int {
+	opts := &Options{Config: config.ConfigFile, stderr: logger.Stderr}
+
+	flags := opts.flags("stationery")
+	flags.Usage = func() { usage(opts.stderr) }
+	// Deprecated: kept so `stationery -preview` works as it used to.
+	preview := flags.Bool("preview", false, "Deprecated: use `stationery build -preview`")
+
+	err := opts.parse(flags, args)
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	if err != nil {
+		return exitCode(err)
+	}
+
+	name := "build"
+	args = flags.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if *preview && name == "build" {
+		args = append([]string{"-preview"}, args...)
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		logger.Error("stationery: unknown command %q", name)
+		usage(opts.stderr)
+		return ExitUsage
+	}
+
+	err = cmd.run(opts, args)
+	if err == flag.ErrHelp {
+		return ExitOK
+	}
+	if err != nil {
+		uerr, isUsage := err.(usageError)
+		if !uerr.shown {
+			logger.Error("stationery %s: %s", name, err)
+		}
+		if isUsage && !uerr.shown {
+			fmt.Fprintf(opts.stderr, "Usage: stationery %s %s\n", name, cmd.usage)
+		}
+		return exitCode(err)
+	}
+
+	return ExitOK
+}
+
+func exitCode(err error) int {
+	if _, ok := err.(usageError); ok {
+		return ExitUsage
+	}
+
+	return ExitError
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aedipamoss/stationery/logger"
+)
+
+func TestExitCodes(t *testing.T) {
+	logger.Stderr = ioutil.Discard
+
+	tests := []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{"version"}, ExitOK},
+		{[]string{"-h"}, ExitOK},
+		{[]string{"build", "-h"}, ExitOK},
+		{[]string{"zomg"}, ExitUsage},
+		{[]string{"-zomg", "build"}, ExitUsage},
+		{[]string{"build", "-zomg"}, ExitUsage},
+		{[]string{"build", "extra"}, ExitUsage},
+		{[]string{"serve", "extra"}, ExitUsage},
+		{[]string{"check", "extra"}, ExitUsage},
+		{[]string{"clean", "extra"}, ExitUsage},
+		{[]string{"version", "extra"}, ExitUsage},
+		{[]string{"init", "-y", "dir", "extra"}, ExitUsage},
+		{[]string{"new", "post"}, ExitUsage},
+		{[]string{"-config", "does/not/exist.yml", "build"}, ExitError},
+		{[]string{"check", "-config", "does/not/exist.yml"}, ExitError},
+	}
+
+	for _, test := range tests {
+		if code := Run(test.args); code != test.expected {
+			t.Errorf("Run(%q) = %d, expected %d", test.args, code, test.expected)
+		}
+	}
+}
+
+func TestFileServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stationery")
+	if err != nil {
+		t.Fatalf("unable to setup temporary dir")
+	}
+	defer os.RemoveAll(dir)
+
+	server := fileServer(dir)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	if rec := get("/missing.html"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without an error page, got %d", rec.Code)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(dir, "zomg.html"), []byte("zomg"), 0644)
+	if err != nil {
+		t.Fatalf("unable to write page")
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "404.html"), []byte("not here"), 0644)
+	if err != nil {
+		t.Fatalf("unable to write error page")
+	}
+
+	if rec := get("/zomg.html"); rec.Code != http.StatusOK || rec.Body.String() != "zomg" {
+		t.Errorf("expected the page, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec := get("/missing/page.html")
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "not here" {
+		t.Errorf("expected the error page, got %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aedipamoss/stationery/archetype"
+	"github.com/aedipamoss/stationery/config"
+	"github.com/aedipamoss/stationery/generate"
+	"github.com/aedipamoss/stationery/logger"
+	"github.com/aedipamoss/stationery/page"
+	"github.com/aedipamoss/stationery/scaffold"
+)
+
+// build is the `stationery build` command, and the default when no command is given.
+func build(opts *Options, args []string) error {
+	flags := opts.flags("build")
+	gen := generate.Options{}
+	flags.BoolVar(&gen.Preview, "preview", false, "Preview changes locally")
+	flags.BoolVar(&gen.Drafts, "drafts", false, "Include drafts")
+	err := opts.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	return generate.Run(cfg, gen)
+}
+
+// serve is the `stationery serve` command.
+// The site is built for the address it is served on, then served until interrupted.
+func serve(opts *Options, args []string) error {
+	flags := opts.flags("serve")
+	addr := flags.String("addr", "localhost:8080", "Address to listen on")
+	gen := generate.Options{}
+	flags.BoolVar(&gen.Drafts, "drafts", false, "Include drafts")
+	err := opts.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	host, port, err := net.SplitHostPort(*addr)
+	if err != nil {
+		return usagef("invalid address %q: %s", *addr, err)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.SiteURL = fmt.Sprintf("http://%s/", net.JoinHostPort(host, port))
+
+	err = generate.Run(cfg, gen)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Serving %s at %s", cfg.Output, cfg.SiteURL)
+	return http.ListenAndServe(*addr, fileServer(cfg.Output))
+}
+
+// Serves the files in dir, answering missing paths with the generated error page.
+func fileServer(dir string) http.Handler {
+	files := http.FileServer(http.Dir(dir))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := http.Dir(dir).Open(path.Clean("/" + r.URL.Path))
+		if err == nil {
+			f.Close() // nolint: errcheck
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		notFound, err := ioutil.ReadFile(filepath.Join(dir, generate.NotFoundFile))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write(notFound) // nolint: errcheck
+	})
+}
+
+// check is the `stationery check` command.
+// Every problem found is printed before failing, so they can all be fixed at once.
+func check(opts *Options, args []string) error {
+	flags := opts.flags("check")
+	gen := generate.Options{}
+	flags.BoolVar(&gen.Drafts, "drafts", false, "Include drafts")
+	err := opts.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	problems := config.CheckFile(opts.Config)
+	if opts.Env != "" {
+		problems = append(problems, config.CheckFile(config.ProfileFile(opts.Config, opts.Env))...)
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	problems = append(problems, cfg.Validate()...)
+	if _, err = os.Stat(cfg.Source); err == nil {
+		problems = append(problems, generate.Check(cfg, gen)...)
+	}
+
+	for _, problem := range problems {
+		logger.Error("%s", problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problem(s)", len(problems))
+	}
+
+	logger.Info("No problems found")
+	return nil
+}
+
+// clean is the `stationery clean` command.
+func clean(opts *Options, args []string) error {
+	flags := opts.flags("clean")
+	err := opts.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	return generate.Clean(cfg)
+}
+
+// Ask for a value on stdin, returning the default when nothing is entered.
+func prompt(in *bufio.Reader, out io.Writer, label string, def string) string {
+	if def != "" {
+		fmt.Fprintf(out, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(out, "%s: ", label)
+	}
+
+	answer, err := in.ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if err != nil && answer == "" {
+		fmt.Fprintln(out)
+		return def
+	}
+	if answer == "" {
+		return def
+	}
+
+	return answer
+}
+
+// initProject is the `stationery init [dir]` command.
+// Any value not given as a flag is asked for interactively, unless -y is set.
+func initProject(opts *Options, args []string) error {
+	flags := opts.flags("init")
+	project := scaffold.Options{}
+	flags.StringVar(&project.Title, "title", "", "Title of the site")
+	flags.StringVar(&project.SiteURL, "site-url", "", "Public URL of the site")
+	flags.StringVar(&project.Author, "author", "", "Name of the author")
+	flags.StringVar(&project.Email, "email", "", "Email of the author")
+	yes := flags.Bool("y", false, "Don't prompt, use flags and defaults only")
+	err := opts.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return usagef("unexpected arguments: %s", strings.Join(flags.Args()[1:], " "))
+	}
+	project.Source = opts.Source
+	project.Output = opts.Output
+
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+
+	if !*yes {
+		in := bufio.NewReader(os.Stdin)
+		project.Title = prompt(in, os.Stdout, "Title", project.Title)
+		project.SiteURL = prompt(in, os.Stdout, "Site URL", project.SiteURL)
+		project.Author = prompt(in, os.Stdout, "Author", project.Author)
+		project.Email = prompt(in, os.Stdout, "Email", project.Email)
+	}
+
+	written, err := scaffold.Init(dir, project)
+	for _, path := range written {
+		logger.Wrote(path)
+	}
+
+	return err
+}
+
+// newContent is the `stationery new <kind> <title>` command.
+func newContent(opts *Options, args []string) error {
+	flags := opts.flags("new")
+	err := opts.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return usagef("expected a kind and a title")
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	path, err := archetype.New(cfg, flags.Arg(0), flags.Arg(1), time.Now())
+	if err != nil {
+		return err
+	}
+
+	logger.Wrote(path)
+	return nil
+}
+
+// publish is the `stationery publish [-rename] <file>` command.
+func publish(opts *Options, args []string) error {
+	flags := opts.flags("publish")
+	rename := flags.Bool("rename", false, "Prefix the file name with the publish date")
+	err := opts.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usagef("expected a file to publish")
+	}
+
+	cfg, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	loc, err := cfg.Location()
+	if err != nil {
+		return err
+	}
+
+	path, err := page.Publish(flags.Arg(0), time.Now().In(loc), *rename)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Published: %s", path)
+	return nil
+}
+
+// version is the `stationery version` command.
+func version(opts *Options, args []string) error {
+	flags := opts.flags("version")
+	err := opts.parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	fmt.Println("stationery", Version)
+	return nil
+}
+
+// help is the `stationery help` command.
+func help(opts *Options, args []string) error {
+	usage(os.Stdout)
+	return nil
+}
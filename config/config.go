@@ -2,11 +2,13 @@
 package config
 
 import (
-	"io/ioutil"
+	"os"
+	"time"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/aedipamoss/stationery/assets"
+	"github.com/aedipamoss/stationery/markdown"
 )
 
 // Config is structure containing the current blog's configuration
@@ -16,14 +18,37 @@ type Config struct {
 	Source  string
 	Layouts []string
 	SiteURL string `yaml:"site-url"`
+	// Markdown turns the extensions and flags of the markdown renderer on or off by name, see markdown.Options
+	Markdown markdown.Options
+	// Permalinks are patterns for the path of pages, keyed by section or DefaultPermalink
+	Permalinks map[string]string
+	// PrettyURLs writes pages as "<slug>/index.html" and links to them as "<slug>/"
+	PrettyURLs bool `yaml:"pretty-urls"`
+	// RelativeURLs makes links relative to each page, so the output works from anywhere
+	RelativeURLs bool `yaml:"relative-urls"`
+	// Redirects are server-side redirect files to write for aliases, see RedirectFormats
+	Redirects []string
+	// Robots is the content of robots.txt, a line pointing to the sitemap is added to it
+	Robots string
+	// Related is how many related posts each post gets, 5 by default and none when negative
+	Related int
+	// Search writes an index of the pages to search them in the browser, see SearchModes
+	Search string
+	// SocialCards renders an image for every page without one, see SocialCardBackground
+	SocialCards bool `yaml:"social-cards"`
+	// SocialCardBackground is an image from the assets drawn behind the text of social cards
+	SocialCardBackground string `yaml:"social-card-background"`
+	// Timezone is an IANA name like "Asia/Tokyo", used for new timestamps
+	Timezone string
 	// RSS fields
 	Title       string
 	Description string
 	Name        string
 	Email       string
-	// Twitter fields
-	Twitter string
-	Image   string
+	// Twitter fields, Twitter is the handle of the site and AuthorTwitter the default one of its pages' author
+	Twitter       string
+	AuthorTwitter string `yaml:"author-twitter"`
+	Image         string
 }
 
 // ConfigFile is the default name for configuration file used by stationery.
@@ -31,8 +56,35 @@ const ConfigFile string = ".station.yml"
 
 // Load will attempt to load the ConfigFile from disk and parse it.
 func Load() (Config, error) {
+	return LoadFile(ConfigFile, "")
+}
+
+// LoadFile is like Load but reads the configuration from the given path.
+//
+// When env is set, the profile for that environment (see ProfileFile) is
+// deep-merged over it. Finally any STATIONERY_* environment variables
+// override individual keys, see EnvVar.
+func LoadFile(path string, env string) (Config, error) {
 	cfg := Config{}
-	content, err := ioutil.ReadFile(ConfigFile)
+	data, err := readFile(path)
+	if err != nil {
+		return cfg, err
+	}
+
+	if env != "" {
+		profile, err := readFile(ProfileFile(path, env))
+		if err != nil {
+			return cfg, err
+		}
+		data = merge(data, profile)
+	}
+
+	err = applyEnv(data, os.LookupEnv)
+	if err != nil {
+		return cfg, err
+	}
+
+	content, err := yaml.Marshal(data)
 	if err != nil {
 		return cfg, err
 	}
@@ -44,3 +96,12 @@ func Load() (Config, error) {
 func (cfg *Config) parse(content []byte) error {
 	return yaml.Unmarshal(content, &cfg)
 }
+
+// Location returns the site's time zone, defaulting to local time when none is set.
+func (cfg Config) Location() (*time.Location, error) {
+	if cfg.Timezone == "" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(cfg.Timezone)
+}
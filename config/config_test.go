@@ -1,6 +1,14 @@
 package config
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
 
 func TestConfig(t *testing.T) {
 	config := &Config{
@@ -52,3 +60,135 @@ output: path/to/output
 		t.Error("no source dir was specified")
 	}
 }
+
+func TestProfileFile(t *testing.T) {
+	if file := ProfileFile(ConfigFile, "production"); file != ".station.production.yml" {
+		t.Errorf("unexpected profile file %s", file)
+	}
+
+	if file := ProfileFile(filepath.Join("conf", "site.yml"), "staging"); file != filepath.Join("conf", "site.staging.yml") {
+		t.Errorf("unexpected profile file %s", file)
+	}
+}
+
+func TestEnvVar(t *testing.T) {
+	if name := EnvVar("site-url"); name != "STATIONERY_SITE_URL" {
+		t.Errorf("unexpected environment variable %s", name)
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stationery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	base := `
+source: src
+output: out
+site-url: http://localhost/
+title: my blog
+assets:
+  css:
+    - site.css
+  images:
+    - avatar.jpg
+`
+	production := `
+site-url: https://example.com/
+assets:
+  css:
+    - site.min.css
+`
+	path := filepath.Join(dir, ConfigFile)
+	err = ioutil.WriteFile(path, []byte(base), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(ProfileFile(path, "production"), []byte(production), 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadFile(path, "production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.SiteURL != "https://example.com/" {
+		t.Errorf("site-url was not overridden by the profile, got %s", cfg.SiteURL)
+	}
+	if cfg.Title != "my blog" || cfg.Source != "src" {
+		t.Errorf("keys missing from the profile were not kept")
+	}
+	if !reflect.DeepEqual(cfg.Assets.CSS, []string{"site.min.css"}) {
+		t.Errorf("assets were not merged, got %v", cfg.Assets.CSS)
+	}
+	if !reflect.DeepEqual(cfg.Assets.Images, []string{"avatar.jpg"}) {
+		t.Errorf("assets were not deep merged, got %v", cfg.Assets.Images)
+	}
+
+	_, err = LoadFile(path, "staging")
+	if err == nil {
+		t.Errorf("missing profile didn't return an error")
+	}
+
+	err = os.Setenv("STATIONERY_SITE_URL", "https://staging.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("STATIONERY_SITE_URL")
+	err = os.Setenv("STATIONERY_OUTPUT", "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("STATIONERY_OUTPUT")
+
+	cfg, err = LoadFile(path, "production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.SiteURL != "https://staging.example.com/" || cfg.Output != "public" {
+		t.Errorf("environment variables didn't override the configuration, got %s and %s", cfg.SiteURL, cfg.Output)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	for _, title := range []string{"yes", "a: b", "# x", "42"} {
+		env := map[string]string{
+			"STATIONERY_TITLE":       title,
+			"STATIONERY_PRETTY_URLS": "true",
+			"STATIONERY_RELATED":     "3",
+		}
+		lookup := func(name string) (string, bool) {
+			value, ok := env[name]
+			return value, ok
+		}
+
+		data := map[interface{}]interface{}{"title": "my blog"}
+		err := applyEnv(data, lookup)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := yaml.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cfg := Config{}
+		err = cfg.parse(content)
+		if err != nil {
+			t.Fatalf("config with title %q from the environment didn't load: %v", title, err)
+		}
+
+		if cfg.Title != title {
+			t.Errorf("title from the environment = %q, expected %q", cfg.Title, title)
+		}
+		if !cfg.PrettyURLs || cfg.Related != 3 {
+			t.Errorf("boolean and number from the environment weren't parsed, got %v and %v", cfg.PrettyURLs, cfg.Related)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix is prepended to a key to find the environment variable overriding it.
+const EnvPrefix string = "STATIONERY_"
+
+// ProfileFile returns the name of the profile for env next to the config at path.
+// For example the "production" profile of ".station.yml" is ".station.production.yml".
+func ProfileFile(path string, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// EnvVar returns the environment variable overriding a configuration key.
+// For example "site-url" is overridden by STATIONERY_SITE_URL.
+func EnvVar(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.Replace(key, "-", "_", -1))
+}
+
+// A top-level key of Config holding a single value, and the kind of that value.
+type scalarKey struct {
+	name string
+	kind reflect.Kind
+}
+
+// Returns the top-level keys of Config which hold a single value, in the order of its fields.
+func scalarKeys() []scalarKey {
+	var keys []scalarKey
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		switch field.Type.Kind() {
+		case reflect.String, reflect.Bool, reflect.Int:
+		default:
+			continue
+		}
+
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		keys = append(keys, scalarKey{name: key, kind: field.Type.Kind()})
+	}
+
+	return keys
+}
+
+// Keys returns the top-level keys of Config which hold a single value,
+// these are the ones that can be overridden by environment variables.
+func Keys() []string {
+	var keys []string
+	for _, key := range scalarKeys() {
+		keys = append(keys, key.name)
+	}
+
+	return keys
+}
+
+// Read a YAML file into a generic map so it can be merged.
+func readFile(path string) (map[interface{}]interface{}, error) {
+	data := make(map[interface{}]interface{})
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return data, err
+	}
+
+	err = yaml.Unmarshal(content, &data)
+	return data, err
+}
+
+// Merge overlay into base, recursing into maps present in both.
+// Any other value in overlay, including lists, replaces the one in base.
+func merge(base, overlay map[interface{}]interface{}) map[interface{}]interface{} {
+	for key, value := range overlay {
+		from, ok := base[key].(map[interface{}]interface{})
+		if !ok {
+			base[key] = value
+			continue
+		}
+
+		to, ok := value.(map[interface{}]interface{})
+		if !ok {
+			base[key] = value
+			continue
+		}
+
+		base[key] = merge(from, to)
+	}
+
+	return base
+}
+
+// Override keys in data with any environment variable set for them.
+// Strings are used as they are, booleans and numbers are parsed as YAML so they keep their type.
+func applyEnv(data map[interface{}]interface{}, lookup func(string) (string, bool)) error {
+	for _, key := range scalarKeys() {
+		value, ok := lookup(EnvVar(key.name))
+		if !ok {
+			continue
+		}
+
+		if key.kind == reflect.String {
+			data[key.name] = value
+			continue
+		}
+
+		var parsed interface{}
+		err := yaml.Unmarshal([]byte(value), &parsed)
+		if err != nil {
+			return err
+		}
+		data[key.name] = parsed
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/aedipamoss/stationery/assets"
+)
+
+// LayoutsDir is the directory layouts are read from.
+const LayoutsDir string = "layouts"
+
+// UnknownKeyRegex matches the error yaml gives for keys that don't exist in a struct.
+var UnknownKeyRegex = regexp.MustCompile(`field (\S+) not found in type .*`)
+
+// Humanize replaces the struct yaml failed to decode into by a shorter message.
+func Humanize(msg string) string {
+	return UnknownKeyRegex.ReplaceAllString(msg, `unknown key "$1"`)
+}
+
+// DefaultPermalink is the key of Permalinks used for pages without a pattern for their section.
+const DefaultPermalink string = "default"
+
+// PermalinkTokenRegex matches the tokens available in Permalinks patterns,
+// like ":year/:month/:slug/".
+const PermalinkTokenRegex string = `:(year|month|day|slug|title|section)\b`
+
+// RedirectFormats are the files which can be listed in Redirects, by name.
+var RedirectFormats = map[string]string{
+	"netlify": "_redirects",
+	"nginx":   "redirects.map",
+}
+
+// SearchModes are the values of Search, what the index holds of each page:
+// all of its text, an excerpt, or an inverted index of the stems of its words.
+var SearchModes = []string{"full", "excerpt", "inverted"}
+
+// CheckFile strictly parses the configuration file at path on its own.
+// Unknown keys and values of the wrong type are reported with their line number.
+func CheckFile(path string) []error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return []error{err}
+	}
+
+	err = yaml.UnmarshalStrict(content, &Config{})
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		var errs []error
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, fmt.Errorf("%s: %s", path, Humanize(msg)))
+		}
+		return errs
+	}
+	if err != nil {
+		return []error{fmt.Errorf("%s: %s", path, err)}
+	}
+
+	return nil
+}
+
+// Report an error when nothing exists at path.
+func mustExist(what string, path string) error {
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s %s does not exist", what, path)
+	}
+
+	return err
+}
+
+// Validate checks the configuration is complete and every path it references exists.
+// All problems found are returned, not only the first one.
+func (cfg Config) Validate() []error {
+	var errs []error
+	add := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if cfg.Output == "" {
+		add(fmt.Errorf("output is required"))
+	}
+
+	if cfg.Source == "" {
+		add(fmt.Errorf("source is required"))
+	} else {
+		add(mustExist("source", cfg.Source))
+	}
+
+	if cfg.SiteURL != "" {
+		u, err := url.Parse(cfg.SiteURL)
+		if err != nil {
+			add(fmt.Errorf("site-url %q is invalid: %s", cfg.SiteURL, err))
+		} else if u.Scheme == "" || u.Host == "" {
+			add(fmt.Errorf("site-url %q must be an absolute URL like https://example.com/", cfg.SiteURL))
+		}
+	}
+
+	if _, err := cfg.Location(); err != nil {
+		add(fmt.Errorf("timezone %q is invalid: %s", cfg.Timezone, err))
+	}
+
+	if cfg.Assets != nil {
+		for _, path := range cfg.Assets.Paths() {
+			add(mustExist("asset", path))
+		}
+	}
+
+	if cfg.SocialCardBackground != "" {
+		add(mustExist("social card background", filepath.Join(assets.Dir, filepath.FromSlash(cfg.SocialCardBackground))))
+	}
+
+	for _, err := range cfg.Markdown.Check() {
+		add(err)
+	}
+
+	tokens := regexp.MustCompile(PermalinkTokenRegex)
+	for key, pattern := range cfg.Permalinks {
+		if unknown := regexp.MustCompile(`:[a-z]+`).FindString(tokens.ReplaceAllString(pattern, "")); unknown != "" {
+			add(fmt.Errorf("permalinks %s %q has unknown token %s", key, pattern, unknown))
+		}
+	}
+
+	for _, format := range cfg.Redirects {
+		if _, ok := RedirectFormats[format]; !ok {
+			add(fmt.Errorf("redirects %q is not one of netlify or nginx", format))
+		}
+	}
+
+	if cfg.Search != "" && !contains(SearchModes, cfg.Search) {
+		add(fmt.Errorf("search %q is not one of %s", cfg.Search, strings.Join(SearchModes, ", ")))
+	}
+
+	for _, layout := range cfg.Layouts {
+		add(mustExist("layout", filepath.Join(LayoutsDir, layout)))
+	}
+
+	return errs
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,10 +1,11 @@
 package fileutils
 
 import (
-	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+
+	"github.com/aedipamoss/stationery/logger"
 )
 
 // Basename returns only the name of a file without any extension.
@@ -41,7 +42,7 @@ func CopyFiles(files []string, src string, dest string) error {
 			return err
 		}
 
-		fmt.Println("Wrote: ", filepath.Join(dest, file))
+		logger.Wrote(filepath.Join(dest, file))
 	}
 
 	return nil
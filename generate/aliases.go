@@ -0,0 +1,156 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aedipamoss/stationery/config"
+	"github.com/aedipamoss/stationery/page"
+)
+
+// AliasTemplate is the page written at each alias, redirecting to the page itself.
+const AliasTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<title>{{ .Title }}</title>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<meta http-equiv="refresh" content="0; url={{ .URL }}">
+<link rel="canonical" href="{{ .URL }}">
+</head>
+<body>
+<p>This page has moved to <a href="{{ .URL }}">{{ .Title }}</a>.</p>
+</body>
+</html>
+`
+
+// A redirect from an alias to the page it belongs to.
+type redirect struct {
+	from string // path of the alias from the root of the site, like "/old.html"
+	page *page.Page
+}
+
+// Returns the path of a URL from the root of the server, like "/blog/zomg.html".
+func serverPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+
+	return u.Path
+}
+
+// Clean an alias into a path from the root of the site, keeping any trailing slash.
+func cleanAlias(alias string) string {
+	clean := strings.TrimPrefix(path.Clean("/"+alias), "/")
+	if strings.HasSuffix(alias, "/") && clean != "" {
+		clean += "/"
+	}
+
+	return clean
+}
+
+// Returns the file written for an alias, relative to the output.
+// An alias without an extension is a directory, like pretty URLs, so it's served as HTML.
+func aliasFile(alias string) string {
+	clean := cleanAlias(alias)
+	if clean != "" && !strings.HasSuffix(clean, "/") && path.Ext(clean) == "" {
+		clean += "/"
+	}
+
+	return page.FilePath(clean)
+}
+
+// Write the redirect page for an alias, refusing to overwrite anything generated before.
+func writeAlias(tmpl *template.Template, alias string, p *page.Page) error {
+	dest := filepath.Join(cfg.Output, aliasFile(alias))
+	if written[filepath.Clean(dest)] {
+		return fmt.Errorf("alias %s of %s collides with %s", alias, p.Source, dest)
+	}
+
+	buf := new(bytes.Buffer)
+	err := tmpl.Execute(buf, p)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(dest), 0700)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(dest, buf.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	wrote(dest)
+	return nil
+}
+
+// Write the server-side redirects file for format, listing every redirect.
+func writeRedirects(format string, redirects []redirect) error {
+	var buf bytes.Buffer
+	for _, r := range redirects {
+		to := serverPath(r.page.URL())
+		switch format {
+		case "netlify":
+			fmt.Fprintf(&buf, "%s %s 301\n", r.from, to)
+		case "nginx":
+			fmt.Fprintf(&buf, "%s %s;\n", r.from, to)
+		}
+	}
+
+	dest := filepath.Join(cfg.Output, config.RedirectFormats[format])
+	err := ioutil.WriteFile(dest, buf.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	wrote(dest)
+	return nil
+}
+
+// Generates a redirect page at each alias of every page,
+// and the server-side redirect files asked for in the config.
+func generateAliases(pages []*page.Page) error {
+	tmpl, err := template.New("alias").Parse(AliasTemplate)
+	if err != nil {
+		return err
+	}
+
+	root := serverPath(rootURI())
+	var redirects []redirect
+	for _, p := range pages {
+		for _, alias := range p.Data.Aliases {
+			err = writeAlias(tmpl, alias, p)
+			if err != nil {
+				return err
+			}
+
+			from := strings.TrimSuffix(root, "/") + "/" + cleanAlias(alias)
+			redirects = append(redirects, redirect{from, p})
+		}
+	}
+
+	sort.Slice(redirects, func(i, j int) bool {
+		return redirects[i].from < redirects[j].from
+	})
+
+	for _, format := range cfg.Redirects {
+		err = writeRedirects(format, redirects)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package generate
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAliasFile(t *testing.T) {
+	tests := map[string]string{
+		"original.html":      "original.html",
+		"/2018/03/original/": filepath.Join("2018", "03", "original", "index.html"),
+		"/old/post":          filepath.Join("old", "post", "index.html"),
+		"../../etc/feed.xml": filepath.Join("etc", "feed.xml"),
+		"/":                  "index.html",
+	}
+
+	for alias, expected := range tests {
+		if file := aliasFile(alias); file != expected {
+			t.Errorf("aliasFile(%q) = %q, expected %q", alias, file, expected)
+		}
+	}
+}
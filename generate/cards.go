@@ -0,0 +1,104 @@
+package generate
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/aedipamoss/stationery/assets"
+	"github.com/aedipamoss/stationery/card"
+	"github.com/aedipamoss/stationery/page"
+)
+
+// CardsDir is the directory of the output social cards are written to.
+const CardsDir = "og"
+
+// Returns the background of social cards from the config, or nil without one.
+func cardBackground() (image.Image, error) {
+	if cfg.SocialCardBackground == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(filepath.Join(assets.Dir, filepath.FromSlash(cfg.SocialCardBackground)))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // nolint: errcheck
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("social card background %s: %s", cfg.SocialCardBackground, err)
+	}
+
+	return img, nil
+}
+
+// Write the social card c as a PNG image to dest.
+func writeCard(c card.Card, dest string) error {
+	err := os.MkdirAll(filepath.Dir(dest), 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	err = c.Encode(f)
+	if err != nil {
+		f.Close() // nolint: errcheck
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	wrote(dest)
+	return nil
+}
+
+// Generates a social card at "og/<slug>.png" for every page without an image of its own,
+// and makes it the image of the page. Slugs shared by pages get a number, like "og/<slug>-2.png".
+func generateCards(pages []*page.Page) error {
+	if !cfg.SocialCards {
+		return nil
+	}
+
+	background, err := cardBackground()
+	if err != nil {
+		return err
+	}
+
+	taken := make(map[string]bool)
+	for _, p := range pages {
+		if p.Data.Image != "" {
+			continue
+		}
+
+		file := path.Join(CardsDir, p.Slug()+".png")
+		for i := 2; taken[file]; i++ {
+			file = path.Join(CardsDir, fmt.Sprintf("%s-%d.png", p.Slug(), i))
+		}
+		taken[file] = true
+
+		c := card.Card{Title: p.Title(), Site: cfg.Title, Background: background}
+		if !p.Standalone() {
+			c.Date = p.DateString()
+		}
+
+		err = writeCard(c, filepath.Join(cfg.Output, filepath.FromSlash(file)))
+		if err != nil {
+			return err
+		}
+		p.Data.Image = file
+		p.ImageWidth = card.Width
+		p.ImageHeight = card.Height
+	}
+
+	return nil
+}
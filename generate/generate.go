@@ -1,16 +1,16 @@
+// Package generate builds the site described by a config.Config.
 package generate
 
 import (
-	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/aedipamoss/stationery/config"
+	"github.com/aedipamoss/stationery/logger"
 	"github.com/aedipamoss/stationery/page"
 
 	"github.com/gorilla/feeds"
@@ -18,6 +18,29 @@ import (
 
 var cfg config.Config
 
+// Options change how the site is built, they usually come from command line flags.
+type Options struct {
+	Preview bool // ignore the site URL and link to files on disk
+	Drafts  bool // include pages marked as drafts
+}
+
+var opts Options
+
+// standalone pages of the site, linked from every page's menu
+var menu []*page.Page
+
+// files written so far, used to detect aliases overwriting a page
+var written map[string]bool
+
+// index, tag and section pages generated so far, listed in the sitemap
+var indexes []*page.Page
+
+// Record a file as written and report it.
+func wrote(path string) {
+	written[filepath.Clean(path)] = true
+	logger.Wrote(path)
+}
+
 func rootURI() string {
 	var path string
 	var err error
@@ -35,40 +58,181 @@ func rootURI() string {
 	return path
 }
 
-// Returns a list of pages sorted by date
-func load(source string) (pages []*page.Page, err error) {
-	var files []os.FileInfo
+// Returns the absolute URL of the root of the site, or nothing without a site URL like when previewing.
+func siteURL() string {
+	if cfg.SiteURL == "" {
+		return ""
+	}
+
+	return rootURI()
+}
+
+// Returns the root of the site relative to the directory of dest, like "../../".
+func relativeRoot(dest string) (string, error) {
+	rel, err := filepath.Rel(filepath.Dir(dest), cfg.Output)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.ToSlash(rel) + "/", nil
+}
+
+// Write a page to its destination, linking relative to it when the config asks to.
+// Two pages sent to the same file by their permalinks would lose one of them, so that's an error.
+func render(p *page.Page) error {
+	if written[filepath.Clean(p.Destination)] {
+		name := p.Source
+		if name == "" {
+			name = "index"
+		}
+		return fmt.Errorf("%s collides with %s, written before it", name, p.Destination)
+	}
+
+	if cfg.RelativeURLs {
+		root, err := relativeRoot(p.Destination)
+		if err != nil {
+			return err
+		}
+		p.Root = root
+		p.RelativeURLs = true
+	}
+
+	err := p.Generate()
+	if err != nil {
+		return err
+	}
+
+	wrote(p.Destination)
+	return nil
+}
+
+// A markdown file found in the source.
+type sourceFile struct {
+	dir  string // directory relative to the source, empty at the top-level
+	info os.FileInfo
+}
+
+// Section directories are the top-level ones of the source.
+func isSection(dir string) bool {
+	return dir != "" && !strings.Contains(filepath.ToSlash(dir), "/")
+}
+
+// Returns the markdown files found in source and all of its sub-directories.
+// Source may also be a single file. Directories starting with a dot are skipped,
+// and so are the IndexFile of sections.
+func sources(source string) ([]sourceFile, error) {
 	file, err := os.Stat(source)
 	if err != nil {
 		return nil, err
 	}
 
 	if !file.IsDir() {
-		files = []os.FileInfo{file}
-	} else {
-		files, err = ioutil.ReadDir(source)
+		return []sourceFile{{info: file}}, nil
 	}
+
+	var files []sourceFile
+	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != source && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if filepath.Ext(info.Name()) != ".md" {
+			return nil
+		}
+
+		dir, err := filepath.Rel(source, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if dir == "." {
+			dir = ""
+		}
+
+		// the index of a section is loaded with it, see sectionIndex
+		if info.Name() == IndexFile && isSection(dir) {
+			return nil
+		}
+
+		files = append(files, sourceFile{dir: dir, info: info})
+		return nil
+	})
+
+	return files, err
+}
+
+// Returns the details of the site shared by every page.
+func site() page.Site {
+	return page.Site{
+		Title:       cfg.Title,
+		Description: cfg.Description,
+		Author:      cfg.Name,
+		Email:       cfg.Email,
+		Image:       cfg.Image,
+		Twitter:     cfg.Twitter,
+		URL:         siteURL(),
+	}
+}
+
+// Returns the twitter handle of the author of pages, the one of the site unless the config has one.
+func authorTwitter() string {
+	if cfg.AuthorTwitter != "" {
+		return cfg.AuthorTwitter
+	}
+
+	return cfg.Twitter
+}
+
+// Returns a page for file with the defaults from the config, ready to be loaded.
+func newPage(file sourceFile) *page.Page {
+	page := &page.Page{}
+	page.Assets = cfg.Assets
+	page.BaseURL = rootURI()
+	page.Dir = file.dir
+	page.FileInfo = file.info
+	page.Markdown = cfg.Markdown
+	page.Permalinks = cfg.Permalinks
+	page.PrettyURLs = cfg.PrettyURLs
+	page.Root = rootURI()
+	page.Site = site()
+	page.Template = filepath.Join(config.LayoutsDir, "page.html")
+	if !cfg.SocialCards {
+		page.Data.Image = cfg.Image
+	}
+	page.Data.Twitter = authorTwitter()
+
+	return page
+}
+
+// Returns a list of pages sorted by date
+func load(source string) (pages []*page.Page, err error) {
+	files, err := sources(source)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, file := range files {
-		if filepath.Ext(file.Name()) != ".md" {
-			continue
-		}
-		page := &page.Page{}
-		page.Assets = cfg.Assets
-		page.FileInfo = file
-		page.Root = rootURI()
-		page.Template = filepath.Join("layouts", "page.html")
-		page.Data.Description = cfg.Description
-		page.Data.Image = cfg.Image
-		page.Data.Twitter = cfg.Twitter
-
+		page := newPage(file)
 		err := page.Load(cfg.Source, cfg.Output)
 		if err != nil {
 			return pages, err
 		}
+		if errs := page.Data.Markdown.Check(); len(errs) > 0 {
+			return pages, fmt.Errorf("%s: %s", page.Source, errs[0])
+		}
+		if page.Data.Draft && !opts.Drafts {
+			logger.Debug("Skipping draft: %s", page.Source)
+			continue
+		}
+		if page.Standalone() {
+			page.Template = layout("standalone.html", "page.html")
+		}
 		pages = append(pages, page)
 	}
 
@@ -79,26 +243,39 @@ func load(source string) (pages []*page.Page, err error) {
 	return pages, nil
 }
 
+// Splits pages into dated posts and standalone pages, which are sorted for the menu.
+func split(pages []*page.Page) (posts []*page.Page, standalone []*page.Page) {
+	for _, page := range pages {
+		if page.Standalone() {
+			standalone = append(standalone, page)
+		} else {
+			posts = append(posts, page)
+		}
+	}
+
+	sort.SliceStable(standalone, func(i, j int) bool {
+		if standalone[i].Data.Weight != standalone[j].Data.Weight {
+			return standalone[i].Data.Weight < standalone[j].Data.Weight
+		}
+		return standalone[i].Title() < standalone[j].Title()
+	})
+
+	return posts, standalone
+}
+
 func generateHTML(pages []*page.Page) error {
 	for _, page := range pages {
-		err := page.Generate()
+		err := render(page)
 		if err != nil {
 			return err
 		}
-		fmt.Println("Wrote: ", page.Destination)
 	}
 
 	return nil
 }
 
-func generateRSS(pages []*page.Page) error {
-	feed := feeds.Feed{
-		Title:       cfg.Title,
-		Link:        &feeds.Link{Href: cfg.SiteURL},
-		Description: cfg.Description,
-		Author:      &feeds.Author{Name: cfg.Name, Email: cfg.Email},
-	}
-
+// Write a feed of pages to dest.
+func writeFeed(feed feeds.Feed, pages []*page.Page, dest string) error {
 	for _, page := range pages {
 		feed.Add(&feeds.Item{
 			Title:       page.Title(),
@@ -113,32 +290,62 @@ func generateRSS(pages []*page.Page) error {
 		return err
 	}
 
-	dest := filepath.Join(cfg.Output, "index.rss")
 	err = ioutil.WriteFile(dest, []byte(rss), 0644)
+	if err != nil {
+		return err
+	}
 
-	fmt.Println("Wrote: ", dest)
-	return err
+	wrote(dest)
+	return nil
 }
 
-func generateIndex(pages []*page.Page) error {
+func generateRSS(pages []*page.Page) error {
+	feed := feeds.Feed{
+		Title:       cfg.Title,
+		Link:        &feeds.Link{Href: cfg.SiteURL},
+		Description: cfg.Description,
+		Author:      &feeds.Author{Name: cfg.Name, Email: cfg.Email},
+	}
+
+	return writeFeed(feed, pages, filepath.Join(cfg.Output, "index.rss"))
+}
+
+// Returns the layout called name, or fallback when the project doesn't have it.
+func layout(name string, fallback string) string {
+	path := filepath.Join(config.LayoutsDir, name)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	return filepath.Join(config.LayoutsDir, fallback)
+}
+
+// Returns a page listing children, with the defaults from the config.
+func newIndex(dest string, children []*page.Page) *page.Page {
 	index := &page.Page{}
 	index.Assets = cfg.Assets
+	index.BaseURL = rootURI()
+	index.Markdown = cfg.Markdown
 	index.Root = rootURI()
+	index.Site = site()
 	index.Data.Title = cfg.Title
 	index.Data.Description = cfg.Description
 	index.Data.Image = cfg.Image
-	index.Data.Twitter = cfg.Twitter
-	index.Destination = filepath.Join(cfg.Output, "index.html")
-	index.Template = filepath.Join("layouts", "index.html")
-	index.Children = pages
+	index.Data.Twitter = authorTwitter()
+	index.Destination = dest
+	index.Template = filepath.Join(config.LayoutsDir, "index.html")
+	index.Children = children
+	index.MenuPages = menu
+	index.PrettyURLs = cfg.PrettyURLs
+
+	return index
+}
 
-	err := index.Generate()
-	if err != nil {
-		return err
-	}
+func generateIndex(pages []*page.Page) error {
+	index := newIndex(filepath.Join(cfg.Output, "index.html"), pages)
+	indexes = append(indexes, index)
 
-	fmt.Println("Wrote: ", index.Destination)
-	return err
+	return render(index)
 }
 
 func buildTagsTree(pages []*page.Page) map[string][]*page.Page {
@@ -157,89 +364,227 @@ func buildTagsTree(pages []*page.Page) map[string][]*page.Page {
 func generateTags(pages []*page.Page) error {
 	tree := buildTagsTree(pages)
 
-	err := os.MkdirAll(filepath.Join(cfg.Output, "tag"), 0700)
-	if err != nil {
-		log.Fatal(err)
+	var tags []string
+	for tag := range tree {
+		tags = append(tags, tag)
 	}
+	sort.Strings(tags)
 
-	for tag, ps := range tree {
-		p := &page.Page{}
-		p.Assets = cfg.Assets
-		p.Root = rootURI()
-		p.Data.Title = cfg.Title
-		p.Data.Description = cfg.Description
-		p.Data.Image = cfg.Image
-		p.Data.Twitter = cfg.Twitter
-		p.Destination = filepath.Join(cfg.Output, "tag", fmt.Sprintf("%s.html", tag))
-		p.Template = filepath.Join("layouts", "index.html")
-		p.Children = ps
+	for _, tag := range tags {
+		p := newIndex("", tree[tag])
+		file := page.FilePath(p.TagPath(tag))
+		p.Dir = filepath.Dir(file)
+		p.Destination = filepath.Join(cfg.Output, file)
 
-		err := p.Generate()
+		err := render(p)
 		if err != nil {
 			return err
 		}
-
-		fmt.Println("Wrote: ", p.Destination)
+		indexes = append(indexes, p)
 	}
 
 	return nil
 }
 
-// Run is the main entrypoint to this program.
-// It's caller is main() and logs any errors that occur during file generation.
+// Run builds the whole site into the configured output directory.
 //
 // I realize this function is complex, it's the main function!
 // nolint: gocyclo
-func Run() {
-	preview := flag.Bool("preview", false, "Preview changes locally")
-	flag.Parse()
-
-	loaded, err := config.Load()
-	if err != nil {
-		log.Fatal(err)
-	}
+func Run(loaded config.Config, options Options) error {
 	cfg = loaded
+	opts = options
+	written = make(map[string]bool)
+	indexes = nil
 
-	if *preview {
+	if opts.Preview {
 		cfg.SiteURL = ""
 	}
 
-	err = os.MkdirAll(cfg.Output, 0700)
+	// a misspelled markdown option would quietly change nothing
+	if errs := cfg.Markdown.Check(); len(errs) > 0 {
+		return errs[0]
+	}
+
+	err := os.MkdirAll(cfg.Output, 0700)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	if cfg.Assets != nil {
 		err = cfg.Assets.Generate(cfg.Output)
 		if err != nil {
-			log.Fatal(err)
+			return err
+		}
+		// copying already reported them, they're only recorded for aliases
+		for _, path := range cfg.Assets.Destinations(cfg.Output) {
+			written[filepath.Clean(path)] = true
 		}
 	}
 
 	pages, err := load(cfg.Source)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	posts, standalone := split(pages)
+	menu = standalone
+	for _, page := range pages {
+		page.MenuPages = menu
+		page.Posts = posts
+	}
+
+	generateRelated(posts)
+	generateSeries(posts)
+
+	sections, err := loadSections(posts)
+	if err != nil {
+		return err
+	}
+	for _, page := range posts {
+		page.SectionPage = sections[page.Section()]
+	}
+
+	err = generateCards(pages)
+	if err != nil {
+		return err
 	}
 
 	err = generateHTML(pages)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	err = generateRSS(posts)
+	if err != nil {
+		return err
+	}
+
+	err = generateIndex(posts)
+	if err != nil {
+		return err
+	}
+
+	err = generateTags(posts)
+	if err != nil {
+		return err
 	}
 
-	err = generateRSS(pages)
+	err = generateSections(sections)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	err = generateIndex(pages)
+	err = generateSearch(pages)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	err = generateTags(pages)
+	err = generateNotFound(posts)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+
+	err = generateSitemap(pages)
+	if err != nil {
+		return err
+	}
+
+	// aliases go last, so they can't overwrite anything else
+	err = generateAliases(pages)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Done!")
+	return nil
+}
+
+// Check validates every page of the site without writing anything.
+// All problems found are returned, each prefixed with the file it was found in.
+func Check(loaded config.Config, options Options) []error {
+	cfg = loaded
+	opts = options
+
+	var errs []error
+	for _, layout := range []string{"page.html", "index.html"} {
+		path := filepath.Join(config.LayoutsDir, layout)
+		if _, err := os.Stat(path); err != nil {
+			errs = append(errs, fmt.Errorf("layout %s is required: %s", path, err))
+		}
+	}
+
+	files, err := sources(cfg.Source)
+	if err != nil {
+		return append(errs, err)
+	}
+
+	for _, file := range files {
+		p := newPage(file)
+		loadErr := p.Load(cfg.Source, cfg.Output)
+
+		content, err := ioutil.ReadFile(p.Source)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// strict parsing explains why loading failed better than the error from Load
+		problems := page.CheckFrontMatter(content)
+		if len(problems) == 0 && loadErr != nil {
+			problems = append(problems, loadErr)
+		}
+
+		for _, problem := range problems {
+			errs = append(errs, fmt.Errorf("%s: %s", p.Source, problem))
+		}
+		logger.Debug("Checked: %s", p.Source)
+	}
+
+	indexes, err := filepath.Glob(filepath.Join(cfg.Source, "*", IndexFile))
+	if err != nil {
+		return append(errs, err)
+	}
+
+	for _, index := range indexes {
+		content, err := ioutil.ReadFile(index)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, problem := range page.CheckFrontMatter(content) {
+			errs = append(errs, fmt.Errorf("%s: %s", index, problem))
+		}
+	}
+
+	return errs
+}
+
+// Clean removes the configured output directory.
+// It refuses to when the sources live inside of it, or it's the current directory.
+func Clean(loaded config.Config) error {
+	output, err := filepath.Abs(loaded.Output)
+	if err != nil {
+		return err
+	}
+
+	source, err := filepath.Abs(loaded.Source)
+	if err != nil {
+		return err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	for _, path := range []string{source, cwd} {
+		rel, err := filepath.Rel(output, path)
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			return fmt.Errorf("refusing to remove output directory %q, it contains %s", loaded.Output, path)
+		}
 	}
 
-	fmt.Println("Done!")
+	logger.Debug("Removing: %s", output)
+	return os.RemoveAll(output)
 }
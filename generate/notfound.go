@@ -0,0 +1,63 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/aedipamoss/stationery/config"
+	"github.com/aedipamoss/stationery/page"
+)
+
+// NotFoundFile is the error page written at the root of the output.
+const NotFoundFile = "404.html"
+
+// NotFoundPosts is how many recent posts the error page gets as its children.
+const NotFoundPosts = 5
+
+// NotFoundTemplate is used for the error page when the project has no layouts/404.html.
+const NotFoundTemplate = `<!DOCTYPE html>
+<html>
+<head>
+{{ .Headers }}
+</head>
+<body>
+  <nav>
+    <a href="{{ .Home }}">home</a>
+    {{ .Menu }}
+  </nav>
+  <h1>Page not found</h1>
+  <p>Sorry, there is nothing here.</p>
+  {{ if .Children }}
+  <h2>Recent posts</h2>
+  <div id="index">
+    {{ .Index }}
+  </div>
+  {{ end }}
+</body>
+</html>
+`
+
+// Generates the error page, served for any missing path of the site.
+// It always links with absolute URLs since it can be served from any path.
+func generateNotFound(pages []*page.Page) error {
+	if len(pages) > NotFoundPosts {
+		pages = pages[:NotFoundPosts]
+	}
+
+	p := newIndex(filepath.Join(cfg.Output, NotFoundFile), pages)
+	p.Data.Title = "Page not found"
+	p.PrettyURLs = false
+
+	p.Template = filepath.Join(config.LayoutsDir, NotFoundFile)
+	if _, err := os.Stat(p.Template); err != nil {
+		p.Layout = NotFoundTemplate
+	}
+
+	err := p.Generate()
+	if err != nil {
+		return err
+	}
+
+	wrote(p.Destination)
+	return nil
+}
@@ -0,0 +1,120 @@
+package generate
+
+import (
+	"math"
+	"sort"
+
+	"github.com/aedipamoss/stationery/page"
+	"github.com/aedipamoss/stationery/search"
+)
+
+// DefaultRelated is how many related posts each post gets when the config doesn't say.
+const DefaultRelated = 5
+
+// Returns the TF-IDF vector of the stems in the title and text of every page, normalized to a length of 1.
+func tfidf(pages []*page.Page) []map[string]float64 {
+	counts := make([]map[string]float64, len(pages))
+	df := make(map[string]float64)
+	for i, p := range pages {
+		counts[i] = make(map[string]float64)
+		for _, word := range search.Words(p.Title() + " " + p.Text()) {
+			counts[i][search.Stem(word)]++
+		}
+		for stem := range counts[i] {
+			df[stem]++
+		}
+	}
+
+	n := float64(len(pages))
+	for _, vector := range counts {
+		var norm float64
+		for stem, count := range vector {
+			// stems found in every page have no weight
+			vector[stem] = count * math.Log(n/df[stem])
+			norm += vector[stem] * vector[stem]
+		}
+
+		norm = math.Sqrt(norm)
+		for stem := range vector {
+			if norm > 0 {
+				vector[stem] /= norm
+			}
+		}
+	}
+
+	return counts
+}
+
+// Returns the cosine similarity of two normalized vectors, from 0 to 1.
+func cosine(a, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+
+	var dot float64
+	for stem, weight := range a {
+		dot += weight * b[stem]
+	}
+
+	return dot
+}
+
+// Sets the related posts of every post, computed once for the whole build.
+//
+// Each shared tag scores 1, to which the similarity of their text is added,
+// so posts sharing more tags come first and their text breaks ties.
+// Posts without any tag or word in common are never related.
+func generateRelated(pages []*page.Page) {
+	limit := cfg.Related
+	if limit == 0 {
+		limit = DefaultRelated
+	}
+	if limit < 0 {
+		return
+	}
+
+	position := make(map[*page.Page]int, len(pages))
+	for i, p := range pages {
+		position[p] = i
+	}
+
+	shared := make([]map[int]float64, len(pages))
+	for i := range pages {
+		shared[i] = make(map[int]float64)
+	}
+	for _, tagged := range buildTagsTree(pages) {
+		for _, a := range tagged {
+			for _, b := range tagged {
+				if a != b {
+					shared[position[a]][position[b]]++
+				}
+			}
+		}
+	}
+
+	vectors := tfidf(pages)
+	for i, p := range pages {
+		scores := make(map[*page.Page]float64)
+		var related []*page.Page
+		for j, other := range pages {
+			if i == j {
+				continue
+			}
+
+			score := shared[i][j] + cosine(vectors[i], vectors[j])
+			if score > 0 {
+				scores[other] = score
+				related = append(related, other)
+			}
+		}
+
+		// pages are sorted by date, so newer posts win ties
+		sort.SliceStable(related, func(a, b int) bool {
+			return scores[related[a]] > scores[related[b]]
+		})
+		if len(related) > limit {
+			related = related[:limit]
+		}
+		p.RelatedPages = related
+	}
+}
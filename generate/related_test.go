@@ -0,0 +1,63 @@
+package generate
+
+import (
+	"html/template"
+	"testing"
+
+	"github.com/aedipamoss/stationery/config"
+	"github.com/aedipamoss/stationery/page"
+)
+
+func TestGenerateRelated(t *testing.T) {
+	post := func(title string, content string, tags ...string) *page.Page {
+		p := &page.Page{Content: template.HTML(content)}
+		p.Data.Title = title
+		p.Data.Tags = tags
+		p.Data.Timestamp = "2018-03-24T10:00:00Z"
+		return p
+	}
+
+	generics := post("generics", "<p>Trying generic functions and generic types.</p>", "go")
+	tests := post("testing", "<p>Table driven tests with subtests.</p>", "go", "tests")
+	types := post("types", "<p>How generic types are checked by the compiler.</p>")
+	cats := post("cats", "<p>Nothing to see here.</p>", "cats")
+	pages := []*page.Page{generics, tests, types, cats}
+
+	cfg = config.Config{Related: 2}
+	generateRelated(pages)
+
+	titles := func(p *page.Page) []string {
+		var titles []string
+		for _, related := range p.RelatedPages {
+			titles = append(titles, related.Title())
+		}
+		return titles
+	}
+
+	expected := map[*page.Page][]string{
+		// the shared tag comes before the similar text
+		generics: {"testing", "types"},
+		tests:    {"generics"},
+		types:    {"generics"},
+		cats:     nil,
+	}
+	for p, want := range expected {
+		got := titles(p)
+		if len(got) != len(want) {
+			t.Errorf("expected %s to be related to %v, got %v", p.Title(), want, got)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("expected %s to be related to %v, got %v", p.Title(), want, got)
+			}
+		}
+	}
+
+	cfg = config.Config{Related: -1}
+	generics.RelatedPages = nil
+	generateRelated(pages)
+	if generics.RelatedPages != nil {
+		t.Errorf("expected no related posts when disabled")
+	}
+}
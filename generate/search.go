@@ -0,0 +1,83 @@
+package generate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aedipamoss/stationery/config"
+	"github.com/aedipamoss/stationery/page"
+	"github.com/aedipamoss/stationery/search"
+)
+
+// SearchFile is the index of the site written when the config asks for search.
+const SearchFile = "search-index.json"
+
+// SearchLayout is the optional layout of a search page, written to "search.html".
+// Its children are all the pages of the site.
+const SearchLayout = "search.html"
+
+// Write the search index of pages, leaving out the ones marked noindex.
+func writeSearchIndex(pages []*page.Page) error {
+	index := search.Index{Documents: []search.Document{}}
+	for _, p := range pages {
+		if p.Data.Noindex {
+			continue
+		}
+
+		doc := search.Document{Title: p.Title(), URL: p.URL(), Tags: p.Data.Tags}
+		if !p.Standalone() {
+			doc.Date = p.Date().Format(time.RFC3339)
+		}
+		if cfg.Search == "excerpt" {
+			// the same text as in feeds, so summaries are cut one way only
+			doc.Text = p.Description()
+		} else {
+			doc.Text = p.Text()
+		}
+		index.Documents = append(index.Documents, doc)
+	}
+
+	if cfg.Search == "inverted" {
+		index.Invert()
+	}
+
+	out, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+
+	dest := filepath.Join(cfg.Output, SearchFile)
+	err = ioutil.WriteFile(dest, out, 0644)
+	if err != nil {
+		return err
+	}
+
+	wrote(dest)
+	return nil
+}
+
+// Generates the search index when the config asks for it,
+// and the search page when the project has a layout for it.
+func generateSearch(pages []*page.Page) error {
+	if cfg.Search != "" {
+		err := writeSearchIndex(pages)
+		if err != nil {
+			return err
+		}
+	}
+
+	layout := filepath.Join(config.LayoutsDir, SearchLayout)
+	if _, err := os.Stat(layout); err != nil {
+		return nil
+	}
+
+	p := newIndex(filepath.Join(cfg.Output, SearchLayout), pages)
+	p.Data.Title = "Search"
+	p.PrettyURLs = false
+	p.Template = layout
+
+	return render(p)
+}
@@ -0,0 +1,60 @@
+package generate
+
+import (
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aedipamoss/stationery/config"
+	"github.com/aedipamoss/stationery/logger"
+	"github.com/aedipamoss/stationery/page"
+	"github.com/aedipamoss/stationery/search"
+)
+
+func TestSearchExcerpt(t *testing.T) {
+	logger.Current = logger.Quiet
+
+	dir, err := ioutil.TempDir("", "stationery")
+	if err != nil {
+		t.Fatalf("unable to setup temporary dir")
+	}
+	defer os.RemoveAll(dir)
+
+	cfg = config.Config{Output: dir, Search: "excerpt"}
+	written = make(map[string]bool)
+
+	described := &page.Page{}
+	described.Data.Type = "page"
+	described.Data.Description = "my own description"
+	long := &page.Page{Content: template.HTML("<p>" + strings.Repeat("word ", page.SummaryWords+1) + "</p>")}
+	long.Data.Type = "page"
+
+	err = writeSearchIndex([]*page.Page{described, long})
+	if err != nil {
+		t.Fatalf("unable to write search index: %v", err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, SearchFile))
+	if err != nil {
+		t.Fatalf("search index was not written")
+	}
+
+	var index search.Index
+	err = json.Unmarshal(content, &index)
+	if err != nil {
+		t.Fatalf("unable to read search index: %v", err)
+	}
+
+	for i, p := range []*page.Page{described, long} {
+		if text := index.Documents[i].Text; text != p.Description() {
+			t.Errorf("excerpt = %q, expected the description %q", text, p.Description())
+		}
+	}
+	if !strings.HasSuffix(index.Documents[1].Text, "…") {
+		t.Errorf("excerpt = %q, expected it cut like summaries", index.Documents[1].Text)
+	}
+}
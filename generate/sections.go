@@ -0,0 +1,102 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/aedipamoss/stationery/page"
+
+	"github.com/gorilla/feeds"
+)
+
+// IndexFile is the optional file in a section directory giving
+// the section's title, description and content.
+const IndexFile = "_index.md"
+
+// Every top-level directory of the source is a section, pages are grouped by it here.
+// Pages at the top-level of the source don't belong to any section.
+func buildSectionsTree(pages []*page.Page) map[string][]*page.Page {
+	tree := make(map[string][]*page.Page)
+	for _, page := range pages {
+		if section := page.Section(); section != "" {
+			tree[section] = append(tree[section], page)
+		}
+	}
+
+	return tree
+}
+
+// Returns the index page of a section, loading its IndexFile when there is one.
+func sectionIndex(section string, children []*page.Page) (*page.Page, error) {
+	index := newIndex(filepath.Join(cfg.Output, section, "index.html"), children)
+	index.Dir = section
+	index.Data.Title = section
+	index.Template = layout("section.html", "index.html")
+
+	info, err := os.Stat(filepath.Join(cfg.Source, section, IndexFile))
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index.FileInfo = info
+	err = index.Load(cfg.Source, cfg.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	// the index is named after its destination rather than IndexFile
+	index.FileInfo = nil
+	index.Destination = filepath.Join(cfg.Output, section, "index.html")
+
+	return index, nil
+}
+
+// Loads the index page of every section of pages, keyed by section.
+func loadSections(pages []*page.Page) (map[string]*page.Page, error) {
+	sections := make(map[string]*page.Page)
+	for section, children := range buildSectionsTree(pages) {
+		index, err := sectionIndex(section, children)
+		if err != nil {
+			return nil, err
+		}
+		sections[section] = index
+	}
+
+	return sections, nil
+}
+
+// Generates an index page and a feed for each section.
+func generateSections(sections map[string]*page.Page) error {
+	var names []string
+	for section := range sections {
+		names = append(names, section)
+	}
+	sort.Strings(names)
+
+	for _, section := range names {
+		index := sections[section]
+		err := render(index)
+		if err != nil {
+			return err
+		}
+		indexes = append(indexes, index)
+
+		feed := feeds.Feed{
+			Title:       index.Title(),
+			Link:        &feeds.Link{Href: index.URL()},
+			Description: index.Description(),
+			Author:      &feeds.Author{Name: cfg.Name, Email: cfg.Email},
+		}
+
+		err = writeFeed(feed, index.Children, filepath.Join(cfg.Output, section, "index.rss"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
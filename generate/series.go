@@ -0,0 +1,36 @@
+package generate
+
+import (
+	"sort"
+
+	"github.com/aedipamoss/stationery/page"
+)
+
+// Sets the parts of its series on every post that has one.
+// Parts are ordered by their `series_order`, then oldest first,
+// parts without one come after the numbered ones so adding one doesn't reorder the others.
+func generateSeries(pages []*page.Page) {
+	series := make(map[string][]*page.Page)
+	for _, p := range pages {
+		if p.Data.Series != "" {
+			series[p.Data.Series] = append(series[p.Data.Series], p)
+		}
+	}
+
+	for _, parts := range series {
+		sort.SliceStable(parts, func(i, j int) bool {
+			a, b := parts[i].Data.SeriesOrder, parts[j].Data.SeriesOrder
+			if (a == 0) != (b == 0) {
+				return b == 0
+			}
+			if a != b {
+				return a < b
+			}
+			return parts[i].Date().Before(parts[j].Date())
+		})
+
+		for _, p := range parts {
+			p.SeriesPages = parts
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/aedipamoss/stationery/page"
+)
+
+func TestGenerateSeries(t *testing.T) {
+	part := func(title string, order int, timestamp string) *page.Page {
+		p := &page.Page{}
+		p.Data.Title = title
+		p.Data.Series = "go"
+		p.Data.SeriesOrder = order
+		p.Data.Timestamp = timestamp
+		return p
+	}
+
+	// newest first, like the posts of a site
+	pages := []*page.Page{
+		part("later", 0, "2018-03-05T10:00:00Z"),
+		part("second", 2, "2018-03-04T10:00:00Z"),
+		part("first", 1, "2018-03-03T10:00:00Z"),
+		part("aside", 0, "2018-03-02T10:00:00Z"),
+	}
+	other := &page.Page{}
+	other.Data.Title = "other"
+
+	generateSeries(append(pages, other))
+
+	var titles []string
+	for _, p := range pages[0].SeriesPages {
+		titles = append(titles, p.Title())
+	}
+
+	expected := []string{"first", "second", "aside", "later"}
+	if len(titles) != len(expected) {
+		t.Fatalf("expected parts %v, got %v", expected, titles)
+	}
+	for i := range expected {
+		if titles[i] != expected[i] {
+			t.Errorf("expected parts %v, got %v", expected, titles)
+			break
+		}
+	}
+
+	if other.SeriesPages != nil {
+		t.Errorf("page outside of any series got parts")
+	}
+}
@@ -0,0 +1,173 @@
+package generate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aedipamoss/stationery/logger"
+	"github.com/aedipamoss/stationery/page"
+)
+
+// SitemapFile is the sitemap written at the root of the output.
+// When the site has more than SitemapLimit URLs it's an index of "sitemap-1.xml", "sitemap-2.xml", etc.
+const SitemapFile = "sitemap.xml"
+
+// SitemapLimit is the most URLs a single sitemap may hold.
+var SitemapLimit = 50000
+
+// RobotsFile is the robots.txt written at the root of the output.
+const RobotsFile = "robots.txt"
+
+// DefaultRobots is used for robots.txt when the config doesn't have any, it allows everything.
+const DefaultRobots = "User-agent: *\nDisallow:\n"
+
+const sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// An entry of a sitemap, see https://www.sitemaps.org/protocol.html
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	Lastmod    string `xml:"lastmod,omitempty"`
+	Changefreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+type urlset struct {
+	XMLName xml.Name     `xml:"urlset"`
+	NS      string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapRef struct {
+	Loc     string `xml:"loc"`
+	Lastmod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapindex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	NS       string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapRef `xml:"sitemap"`
+}
+
+// Returns the date a page was last modified, index pages change with their newest child.
+func lastmod(p *page.Page) time.Time {
+	date := p.Modified()
+	if p.FileInfo == nil && p.Data.Timestamp == "" && len(p.Children) > 0 {
+		date = p.Children[0].Date()
+	}
+
+	return date
+}
+
+// Returns the sitemap entries for pages, leaving out the ones marked noindex.
+func sitemapURLs(pages []*page.Page) []sitemapURL {
+	var urls []sitemapURL
+	for _, p := range pages {
+		// pages copied from elsewhere leave the sitemap to the original
+		if p.Data.Noindex || p.Canonical() != p.URL() {
+			continue
+		}
+
+		entry := sitemapURL{
+			Loc:        p.URL(),
+			Lastmod:    lastmod(p).Format(time.RFC3339),
+			Changefreq: p.Data.Changefreq,
+		}
+		if p.Data.Priority != nil {
+			entry.Priority = strconv.FormatFloat(*p.Data.Priority, 'f', -1, 64)
+		}
+		urls = append(urls, entry)
+	}
+
+	return urls
+}
+
+// Write v as an XML document to dest.
+func writeXML(dest string, v interface{}) error {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(dest, append([]byte(xml.Header), append(out, '\n')...), 0644)
+	if err != nil {
+		return err
+	}
+
+	wrote(dest)
+	return nil
+}
+
+// Write the sitemap for urls, split in parts listed by a sitemap index past SitemapLimit.
+func writeSitemap(urls []sitemapURL) error {
+	dest := filepath.Join(cfg.Output, SitemapFile)
+	if len(urls) <= SitemapLimit {
+		return writeXML(dest, urlset{NS: sitemapNS, URLs: urls})
+	}
+
+	index := sitemapindex{NS: sitemapNS}
+	for i := 0; i*SitemapLimit < len(urls); i++ {
+		part := urls[i*SitemapLimit:]
+		if len(part) > SitemapLimit {
+			part = part[:SitemapLimit]
+		}
+
+		name := fmt.Sprintf("sitemap-%d.xml", i+1)
+		err := writeXML(filepath.Join(cfg.Output, name), urlset{NS: sitemapNS, URLs: part})
+		if err != nil {
+			return err
+		}
+
+		ref := sitemapRef{Loc: rootURI() + name}
+		for _, u := range part {
+			if u.Lastmod > ref.Lastmod {
+				ref.Lastmod = u.Lastmod
+			}
+		}
+		index.Sitemaps = append(index.Sitemaps, ref)
+	}
+
+	return writeXML(dest, index)
+}
+
+// Write robots.txt from the config, pointing to the sitemap.
+func writeRobots() error {
+	robots := cfg.Robots
+	if robots == "" {
+		robots = DefaultRobots
+	}
+	if !strings.HasSuffix(robots, "\n") {
+		robots += "\n"
+	}
+	robots += fmt.Sprintf("\nSitemap: %s%s\n", rootURI(), SitemapFile)
+
+	dest := filepath.Join(cfg.Output, RobotsFile)
+	err := ioutil.WriteFile(dest, []byte(robots), 0644)
+	if err != nil {
+		return err
+	}
+
+	wrote(dest)
+	return nil
+}
+
+// Generates the sitemap of pages and the indexes, along with robots.txt.
+// Sitemaps need absolute URLs, so nothing is written without a site URL.
+func generateSitemap(pages []*page.Page) error {
+	if cfg.SiteURL == "" {
+		logger.Debug("Skipping sitemap without a site URL")
+		return nil
+	}
+
+	urls := sitemapURLs(append(indexes, pages...))
+	err := writeSitemap(urls)
+	if err != nil {
+		return err
+	}
+
+	return writeRobots()
+}
@@ -0,0 +1,92 @@
+package generate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aedipamoss/stationery/config"
+	"github.com/aedipamoss/stationery/logger"
+	"github.com/aedipamoss/stationery/page"
+)
+
+func TestWriteSitemapSplits(t *testing.T) {
+	logger.Current = logger.Quiet
+
+	dir, err := ioutil.TempDir("", "stationery")
+	if err != nil {
+		t.Fatalf("unable to setup temporary dir")
+	}
+	defer os.RemoveAll(dir)
+
+	cfg = config.Config{Output: dir, SiteURL: "https://example.com/blog/"}
+	written = make(map[string]bool)
+	defer func(limit int) { SitemapLimit = limit }(SitemapLimit)
+	SitemapLimit = 2
+
+	urls := []sitemapURL{
+		{Loc: "https://example.com/blog/a.html", Lastmod: "2018-03-01T00:00:00Z"},
+		{Loc: "https://example.com/blog/b.html", Lastmod: "2018-03-03T00:00:00Z"},
+		{Loc: "https://example.com/blog/c.html", Lastmod: "2018-03-02T00:00:00Z"},
+	}
+
+	err = writeSitemap(urls)
+	if err != nil {
+		t.Fatalf("unable to write sitemap: %v", err)
+	}
+
+	read := func(name string) string {
+		content, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("%s was not written", name)
+		}
+		return string(content)
+	}
+
+	index := read(SitemapFile)
+	for _, expected := range []string{
+		"<sitemapindex",
+		"<loc>https://example.com/blog/sitemap-1.xml</loc>",
+		"<lastmod>2018-03-03T00:00:00Z</lastmod>",
+		"<loc>https://example.com/blog/sitemap-2.xml</loc>",
+		"<lastmod>2018-03-02T00:00:00Z</lastmod>",
+	} {
+		if !strings.Contains(index, expected) {
+			t.Errorf("expected sitemap index to contain %q, got:\n%s", expected, index)
+		}
+	}
+
+	if first := read("sitemap-1.xml"); strings.Count(first, "<url>") != 2 {
+		t.Errorf("expected 2 URLs in the first sitemap, got:\n%s", first)
+	}
+	if second := read("sitemap-2.xml"); !strings.Contains(second, "c.html") {
+		t.Errorf("expected the last URL in the second sitemap, got:\n%s", second)
+	}
+}
+
+func TestSitemapPriority(t *testing.T) {
+	priority := func(p float64) *float64 { return &p }
+	tests := []struct {
+		priority *float64
+		expected string
+	}{
+		{nil, ""},
+		{priority(0), "0"},
+		{priority(0.25), "0.25"},
+		{priority(1), "1"},
+	}
+
+	for _, test := range tests {
+		p := &page.Page{Root: "https://example.com/", Destination: "zomg.html"}
+		p.Site.URL = "https://example.com/"
+		p.Data.Timestamp = "2018-03-24T12:43:03Z"
+		p.Data.Priority = test.priority
+
+		urls := sitemapURLs([]*page.Page{p})
+		if len(urls) != 1 || urls[0].Priority != test.expected {
+			t.Errorf("expected priority %q, got %v", test.expected, urls)
+		}
+	}
+}
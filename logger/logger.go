@@ -0,0 +1,55 @@
+// Package logger prints progress and errors at the verbosity chosen on the command line.
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Level is how much output is printed.
+type Level int
+
+const (
+	// Quiet only prints errors.
+	Quiet Level = iota
+	// Normal prints every file written, this is the default.
+	Normal
+	// Verbose also prints debugging details about what is going on.
+	Verbose
+)
+
+// Current is the level used by every function in this package.
+var Current = Normal
+
+// Stdout and Stderr are where messages are written, they can be swapped out in tests.
+var (
+	Stdout io.Writer = os.Stdout
+	Stderr io.Writer = os.Stderr
+)
+
+// Wrote reports a file that was written to disk.
+func Wrote(path string) {
+	if Current >= Normal {
+		fmt.Fprintln(Stdout, "Wrote: ", path)
+	}
+}
+
+// Info prints a message unless running quietly.
+func Info(format string, args ...interface{}) {
+	if Current >= Normal {
+		fmt.Fprintf(Stdout, format+"\n", args...)
+	}
+}
+
+// Debug prints a message only when running verbosely.
+func Debug(format string, args ...interface{}) {
+	if Current >= Verbose {
+		fmt.Fprintf(Stdout, format+"\n", args...)
+	}
+}
+
+// Error always prints a message to Stderr.
+func Error(format string, args ...interface{}) {
+	fmt.Fprintf(Stderr, format+"\n", args...)
+}
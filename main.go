@@ -3,10 +3,9 @@ package main
 import (
 	"os"
 
-	"github.com/aedipamoss/stationery/generate"
+	"github.com/aedipamoss/stationery/cli"
 )
 
 func main() {
-	generate.Run()
-	os.Exit(0)
+	os.Exit(cli.Run(os.Args[1:]))
 }
@@ -2,7 +2,10 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
+	"image"
+	"image/png"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -13,6 +16,7 @@ import (
 
 func TestStationery(t *testing.T) {
 	if os.Getenv("BE_STATIONERY") == "1" {
+		os.Args = append(os.Args[:1], flag.Args()...)
 		main()
 		return
 	}
@@ -73,7 +77,7 @@ this file should be ignored!`)
 		t.Fatalf("unable to read temporary post after parsing")
 	}
 
-	mustContain(t, page, "<h1>zomg</h1>")
+	mustContain(t, page, `<h1 id="zomg">zomg</h1>`)
 	mustContain(t, page, "<title>zomg is a thing</title>")
 	mustNotContain(t, page, "<h2>title: zomg is a thing</h2>")
 }
@@ -117,7 +121,7 @@ this is my temp post!`)
 		t.Fatalf("unable to read temporary post after parsing")
 	}
 
-	mustContain(t, page, "<h1>zomg all the things</h1>")
+	mustContain(t, page, `<h1 id="zomg-all-the-things">zomg all the things</h1>`)
 	mustContain(t, page, "<title>log of all zomg</title>")
 	mustNotContain(t, page, "<h2>title: log of all zomg</h2>")
 }
@@ -196,80 +200,2332 @@ look, i have no data!`)
 	mustContain(t, index, `<div id="index">`)
 }
 
+func TestNestedSource(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "notes", "go"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = mkdir(filepath.Join(tmpProject, "src", ".git"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project hidden dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "notes", "go", "generics.md"), `
+---
+title: generics
+tags:
+  - go
+---
+
+# generics`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", ".git", "hidden.md"), `
+# hidden`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	if _, err = os.Stat(filepath.Join(tmpProject, "out", "hidden.html")); err == nil {
+		t.Fatalf("file in a hidden directory was generated")
+	}
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "notes", "go", "generics.html"))
+	if err != nil {
+		t.Fatalf("nested post was not generated at the nested path")
+	}
+
+	mustContain(t, page, `<meta property="og:url" content="https://example.com/notes/go/generics.html" />`)
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, index, `<a href="https://example.com/notes/go/generics.html">generics</a>`)
+
+	tag, err := readTmpPost(filepath.Join(tmpProject, "out", "tag", "go.html"))
+	if err != nil {
+		t.Fatalf("unable to read tag page")
+	}
+
+	mustContain(t, tag, `<a href="https://example.com/notes/go/generics.html">generics</a>`)
+
+	rss, err := readTmpPost(filepath.Join(tmpProject, "out", "index.rss"))
+	if err != nil {
+		t.Fatalf("unable to read feed")
+	}
+
+	mustContain(t, rss, `<link>https://example.com/notes/go/generics.html</link>`)
+}
+
+func TestSections(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "notes", "go"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = mkdir(filepath.Join(tmpProject, "src", "links"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "section.html"), `
+<html>
+<head>
+{{ .Headers }}
+</head>
+<body>
+  <h1>{{ .Title }} in {{ .Section }}</h1>
+  {{ .Content }}
+  <div id="section">
+    {{ .Index }}
+  </div>
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create section layout")
+	}
+
+	posts := map[string]string{
+		filepath.Join("notes", "_index.md"): `---
+title: My notes
+description: things i wrote down
+---
+
+Notes about **things**.`,
+		filepath.Join("notes", "first.md"):          "# first note",
+		filepath.Join("notes", "go", "generics.md"): "# generics",
+		filepath.Join("notes", "go", "_index.md"):   "# not a section",
+		filepath.Join("links", "cool.md"):           "# cool link",
+		"top.md":                                    "# top",
+	}
+	for path, post := range posts {
+		err = tmpPostSetup(filepath.Join(tmpProject, "src", path), post)
+		if err != nil {
+			t.Fatalf("unable to create temporary post")
+		}
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	if _, err = os.Stat(filepath.Join(tmpProject, "out", "notes", "_index.html")); err == nil {
+		t.Fatalf("section index file was generated as a page")
+	}
+
+	// only top-level directories are sections, an index deeper down is a regular page
+	if _, err = os.Stat(filepath.Join(tmpProject, "out", "notes", "go", "_index.html")); err != nil {
+		t.Fatalf("index file outside of a section directory was not generated as a page")
+	}
+	if _, err = os.Stat(filepath.Join(tmpProject, "out", "notes", "go", "index.html")); err == nil {
+		t.Fatalf("sub-directory of a section was generated as a section")
+	}
+
+	notes, err := readTmpPost(filepath.Join(tmpProject, "out", "notes", "index.html"))
+	if err != nil {
+		t.Fatalf("section index was not generated")
+	}
+
+	mustContain(t, notes, "<title>My notes</title>")
+	mustContain(t, notes, `<meta name="description" content="things i wrote down" />`)
+	mustContain(t, notes, "<h1>My notes in notes</h1>")
+	mustContain(t, notes, "<p>Notes about <strong>things</strong>.</p>")
+	mustContain(t, notes, `<a href="https://example.com/notes/first.html">first</a>`)
+	mustContain(t, notes, `<a href="https://example.com/notes/go/generics.html">generics</a>`)
+	mustNotContain(t, notes, "top.html")
+	mustNotContain(t, notes, "cool.html")
+
+	links, err := readTmpPost(filepath.Join(tmpProject, "out", "links", "index.html"))
+	if err != nil {
+		t.Fatalf("section index was not generated")
+	}
+
+	mustContain(t, links, "<title>links</title>")
+	mustContain(t, links, `<a href="https://example.com/links/cool.html">cool</a>`)
+	mustNotContain(t, links, "first.html")
+
+	rss, err := readTmpPost(filepath.Join(tmpProject, "out", "notes", "index.rss"))
+	if err != nil {
+		t.Fatalf("section feed was not generated")
+	}
+
+	mustContain(t, rss, "<title>My notes</title>")
+	mustContain(t, rss, "<link>https://example.com/notes/index.html</link>")
+	mustContain(t, rss, "<link>https://example.com/notes/go/generics.html</link>")
+	mustNotContain(t, rss, "cool.html")
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, index, `<a href="https://example.com/top.html">top</a>`)
+	mustContain(t, index, `<a href="https://example.com/links/cool.html">cool</a>`)
+}
+
+func TestStandalonePages(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "pages"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "page.html"), `
+<html>
+<body>
+  <nav>{{ .Menu }}</nav>
+  {{ .Content }}
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create page layout")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "standalone.html"), `
+<html>
+<body>
+  <div id="standalone">{{ .Content }}</div>
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create standalone layout")
+	}
+
+	posts := map[string]string{
+		"about.md": `---
+title: About
+type: page
+weight: 2
+tags:
+  - me
+---
+
+# about me`,
+		filepath.Join("pages", "now.md"): `---
+title: Now
+weight: 1
+---
+
+# what i'm doing now`,
+		"post.md": `---
+title: a post
+tags:
+  - me
+---
+
+# post`,
+	}
+	for path, post := range posts {
+		err = tmpPostSetup(filepath.Join(tmpProject, "src", path), post)
+		if err != nil {
+			t.Fatalf("unable to create temporary post")
+		}
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	about, err := readTmpPost(filepath.Join(tmpProject, "out", "about.html"))
+	if err != nil {
+		t.Fatalf("standalone page was not generated")
+	}
+
+	mustContain(t, about, `<div id="standalone"><h1 id="about-me">about me</h1>`)
+
+	now, err := readTmpPost(filepath.Join(tmpProject, "out", "pages", "now.html"))
+	if err != nil {
+		t.Fatalf("page in the pages directory was not generated")
+	}
+
+	mustContain(t, now, `<div id="standalone">`)
+
+	post, err := readTmpPost(filepath.Join(tmpProject, "out", "post.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, post, `<li><a href="https://example.com/pages/now.html">Now</a></li>
+	<li><a href="https://example.com/about.html">About</a></li>`)
+
+	for _, path := range []string{"index.html", "index.rss", filepath.Join("tag", "me.html")} {
+		listing, err := readTmpPost(filepath.Join(tmpProject, "out", path))
+		if err != nil {
+			t.Fatalf("unable to read %s", path)
+		}
+
+		mustContain(t, listing, "https://example.com/post.html")
+		mustNotContain(t, listing, "about.html")
+		mustNotContain(t, listing, "now.html")
+	}
+
+	if _, err = os.Stat(filepath.Join(tmpProject, "out", "pages", "index.html")); err == nil {
+		t.Fatalf("pages directory was generated as a section")
+	}
+}
+
+func TestPermalinks(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+permalinks:
+  default: :year/:month/:slug/
+  notes: :section/:title.html
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "notes"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	posts := map[string]string{
+		"zomg.md": `---
+title: zomg is a thing
+timestamp: 2018-03-24T12:43:03Z
+slug: zomg-renamed
+tags:
+  - foo
+---
+
+# zomg`,
+		filepath.Join("notes", "generics.md"): `---
+title: Generics, finally
+---
+
+# generics`,
+		"about.md": `---
+title: About
+type: page
+---
+
+# about`,
+	}
+	for path, post := range posts {
+		err = tmpPostSetup(filepath.Join(tmpProject, "src", path), post)
+		if err != nil {
+			t.Fatalf("unable to create temporary post")
+		}
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	for _, path := range []string{
+		filepath.Join("2018", "03", "zomg-renamed", "index.html"),
+		filepath.Join("notes", "generics-finally.html"),
+		"about.html",
+	} {
+		if _, err = os.Stat(filepath.Join(tmpProject, "out", path)); err != nil {
+			t.Fatalf("%s was not generated", path)
+		}
+	}
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "2018", "03", "zomg-renamed", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, page, `<meta property="og:url" content="https://example.com/2018/03/zomg-renamed/" />`)
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, index, `<a href="https://example.com/2018/03/zomg-renamed/">zomg is a thing</a>`)
+	mustContain(t, index, `<a href="https://example.com/notes/generics-finally.html">Generics, finally</a>`)
+
+	tag, err := readTmpPost(filepath.Join(tmpProject, "out", "tag", "foo.html"))
+	if err != nil {
+		t.Fatalf("unable to read tag page")
+	}
+
+	mustContain(t, tag, `<a href="https://example.com/2018/03/zomg-renamed/">zomg is a thing</a>`)
+
+	rss, err := readTmpPost(filepath.Join(tmpProject, "out", "index.rss"))
+	if err != nil {
+		t.Fatalf("unable to read feed")
+	}
+
+	mustContain(t, rss, `<link>https://example.com/2018/03/zomg-renamed/</link>`)
+}
+
+func TestPrettyURLs(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+pretty-urls: true
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "notes"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "page.html"), `
+<html>
+<head>
+{{ .Headers }}
+</head>
+<body>
+  {{ .Content }}
+  {{ .Tags }}
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create page layout")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "notes", "zomg.md"), `
+---
+title: zomg is a thing
+tags:
+  - foo
+---
+
+# zomg`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "notes", "zomg", "index.html"))
+	if err != nil {
+		t.Fatalf("post was not generated as a directory index")
+	}
+
+	mustContain(t, page, `<meta property="og:url" content="https://example.com/notes/zomg/" />`)
+	mustContain(t, page, `<a href="https://example.com/tag/foo/">foo</a>`)
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, index, `<meta property="og:url" content="https://example.com/" />`)
+	mustContain(t, index, `<a href="https://example.com/notes/zomg/">zomg is a thing</a>`)
+
+	tag, err := readTmpPost(filepath.Join(tmpProject, "out", "tag", "foo", "index.html"))
+	if err != nil {
+		t.Fatalf("tag page was not generated as a directory index")
+	}
+
+	mustContain(t, tag, `<meta property="og:url" content="https://example.com/tag/foo/" />`)
+	mustContain(t, tag, `<a href="https://example.com/notes/zomg/">zomg is a thing</a>`)
+
+	section, err := readTmpPost(filepath.Join(tmpProject, "out", "notes", "index.html"))
+	if err != nil {
+		t.Fatalf("section index was not generated")
+	}
+
+	mustContain(t, section, `<meta property="og:url" content="https://example.com/notes/" />`)
+
+	rss, err := readTmpPost(filepath.Join(tmpProject, "out", "index.rss"))
+	if err != nil {
+		t.Fatalf("unable to read feed")
+	}
+
+	mustContain(t, rss, `<link>https://example.com/notes/zomg/</link>`)
+}
+
+func TestSubpathSiteURL(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/blog
+pretty-urls: true
+redirects: [netlify]
+assets:
+  css:
+    - style.css`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "zomg.md"), `
+---
+title: zomg is a thing
+aliases:
+  - old.html
+---
+
+# zomg`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "zomg", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, page, `<meta property="og:url" content="https://example.com/blog/zomg/" />`)
+	mustContain(t, page, `href="https://example.com/blog/css/style.css"`)
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read index")
+	}
+
+	mustContain(t, index, `<meta property="og:url" content="https://example.com/blog/" />`)
+	mustContain(t, index, `<a href="https://example.com/blog/zomg/">zomg is a thing</a>`)
+
+	redirects, err := readTmpPost(filepath.Join(tmpProject, "out", "_redirects"))
+	if err != nil {
+		t.Fatalf("unable to read redirects")
+	}
+
+	mustContain(t, redirects, "/blog/old.html /blog/zomg/ 301")
+}
+
+func TestRelativeURLs(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/blog/
+relative-urls: true
+assets:
+  css:
+    - style.css`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "notes", "go"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "page.html"), `
+<html>
+<head>
+{{ .Headers }}
+</head>
+<body>
+  <a href="{{ .Home }}">home</a>
+  {{ .Content }}
+  {{ .Tags }}
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create page layout")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "notes", "go", "generics.md"), `
+---
+title: generics
+tags:
+  - go
+---
+
+# generics`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "notes", "go", "generics.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, page, `href="../../css/style.css"`)
+	mustContain(t, page, `<a href="../../index.html">home</a>`)
+	mustContain(t, page, `<a href="../../tag/go.html">go</a>`)
+	mustContain(t, page, `<meta property="og:url" content="https://example.com/blog/notes/go/generics.html" />`)
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read index")
+	}
+
+	mustContain(t, index, `href="./css/style.css"`)
+	mustContain(t, index, `<a href="./notes/go/generics.html">generics</a>`)
+
+	tag, err := readTmpPost(filepath.Join(tmpProject, "out", "tag", "go.html"))
+	if err != nil {
+		t.Fatalf("unable to read tag page")
+	}
+
+	mustContain(t, tag, `<a href="../notes/go/generics.html">generics</a>`)
+
+	section, err := readTmpPost(filepath.Join(tmpProject, "out", "notes", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read section index")
+	}
+
+	mustContain(t, section, `<a href="../notes/go/generics.html">generics</a>`)
+
+	rss, err := readTmpPost(filepath.Join(tmpProject, "out", "index.rss"))
+	if err != nil {
+		t.Fatalf("unable to read feed")
+	}
+
+	mustContain(t, rss, "https://example.com/blog/notes/go/generics.html")
+}
+
+func TestNotFound(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/blog/
+relative-urls: true
+assets:
+  css:
+    - style.css`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "notes"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "notes", "zomg.md"), `
+---
+title: zomg is a thing
+---
+
+# zomg`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	notFound, err := readTmpPost(filepath.Join(tmpProject, "out", "404.html"))
+	if err != nil {
+		t.Fatalf("error page was not generated")
+	}
+
+	mustContain(t, notFound, "Page not found")
+	mustContain(t, notFound, `href="https://example.com/blog/css/style.css"`)
+	mustContain(t, notFound, `<a href="https://example.com/blog/notes/zomg.html">zomg is a thing</a>`)
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "404.html"), `
+<html>
+<body>
+  <h1>Lost: {{ .Title }}</h1>
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create error layout")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	notFound, err = readTmpPost(filepath.Join(tmpProject, "out", "404.html"))
+	if err != nil {
+		t.Fatalf("error page was not generated")
+	}
+
+	mustContain(t, notFound, "<h1>Lost: Page not found</h1>")
+}
+
+func TestSitemap(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/blog
+robots: |
+  User-agent: *
+  Disallow: /drafts/
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "notes"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "notes", "zomg.md"), `
+---
+title: zomg is a thing
+timestamp: 2018-03-24T10:00:00Z
+changefreq: monthly
+priority: 0.8
+tags:
+  - foo
+---
+
+# zomg`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "secret.md"), `
+---
+title: secret
+timestamp: 2018-03-20T10:00:00Z
+noindex: true
+---
+
+# secret`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	sitemap, err := readTmpPost(filepath.Join(tmpProject, "out", "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("sitemap was not generated")
+	}
+
+	mustContain(t, sitemap, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	mustContain(t, sitemap, `<loc>https://example.com/blog/index.html</loc>`)
+	mustContain(t, sitemap, `<loc>https://example.com/blog/notes/index.html</loc>`)
+	mustContain(t, sitemap, `<loc>https://example.com/blog/tag/foo.html</loc>`)
+	mustContain(t, sitemap, `<loc>https://example.com/blog/notes/zomg.html</loc>
+    <lastmod>2018-03-24T10:00:00Z</lastmod>
+    <changefreq>monthly</changefreq>
+    <priority>0.8</priority>`)
+	mustNotContain(t, sitemap, "secret")
+	mustNotContain(t, sitemap, "404")
+
+	secret, err := readTmpPost(filepath.Join(tmpProject, "out", "secret.html"))
+	if err != nil {
+		t.Fatalf("noindex page was not generated")
+	}
+
+	mustContain(t, secret, `<meta name="robots" content="noindex" />`)
+
+	robots, err := readTmpPost(filepath.Join(tmpProject, "out", "robots.txt"))
+	if err != nil {
+		t.Fatalf("robots.txt was not generated")
+	}
+
+	mustContain(t, robots, "Disallow: /drafts/")
+	mustContain(t, robots, "Sitemap: https://example.com/blog/sitemap.xml")
+}
+
+func TestStructuredData(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+title: my blog
+name: Jane Doe
+email: jane@example.com
+image: images/avatar.jpg
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "notes"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "notes", "zomg.md"), `
+---
+title: zomg </script> is a thing
+timestamp: 2018-03-24T10:00:00Z
+tags:
+  - foo
+  - bar
+jsonld:
+  "@type": TechArticle
+  proficiencyLevel: Beginner
+---
+
+# zomg`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "notes", "_index.md"), `---
+title: Notes
+---`)
+	if err != nil {
+		t.Fatalf("unable to create section index")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "notes", "zomg.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, page, `<script type="application/ld+json">{"@context":"https://schema.org","@type":"TechArticle",`)
+	mustContain(t, page, `"author":{"@type":"Person","email":"jane@example.com","image":"https://example.com/images/avatar.jpg","name":"Jane Doe"}`)
+	mustContain(t, page, `"datePublished":"2018-03-24T10:00:00Z"`)
+	mustContain(t, page, `"headline":"zomg \u003c/script\u003e is a thing"`)
+	mustContain(t, page, `"keywords":"foo, bar"`)
+	mustContain(t, page, `"proficiencyLevel":"Beginner"`)
+	mustContain(t, page, `"@type":"BreadcrumbList"`)
+	mustContain(t, page, `{"@type":"ListItem","item":"https://example.com/index.html","name":"my blog","position":1}`)
+	mustContain(t, page, `{"@type":"ListItem","item":"https://example.com/notes/index.html","name":"Notes","position":2}`)
+	mustContain(t, page, `"item":"https://example.com/notes/zomg.html","name":"zomg \u003c/script\u003e is a thing","position":3}`)
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read index")
+	}
+
+	mustContain(t, index, `{"@context":"https://schema.org","@type":"WebSite","name":"my blog","url":"https://example.com/index.html"}`)
+	mustContain(t, index, `{"@context":"https://schema.org","@type":"Person","email":"jane@example.com"`)
+	mustNotContain(t, index, "BlogPosting")
+
+	// previews are on disk, so they have no URLs to give
+	err = execCommandWithProject(tmpProject, "build", "-preview")
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	page, err = readTmpPost(filepath.Join(tmpProject, "out", "notes", "zomg.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, page, `{"@type":"ListItem","name":"Notes","position":2}`)
+	mustNotContain(t, page, `"item":`)
+
+	index, err = readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read index")
+	}
+
+	mustContain(t, index, `{"@context":"https://schema.org","@type":"WebSite","name":"my blog"}`)
+}
+
+func TestSocialMetaData(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+title: my blog
+twitter: myblog
+author-twitter: jane
+assets:
+  images:
+    - cover.png`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = mkdir(filepath.Join(tmpProject, "assets", "images"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project images dir")
+	}
+
+	var cover bytes.Buffer
+	err = png.Encode(&cover, image.NewRGBA(image.Rect(0, 0, 1200, 630)))
+	if err != nil {
+		t.Fatalf("unable to encode image")
+	}
+
+	err = ioutil.WriteFile(filepath.Join(tmpProject, "assets", "images", "cover.png"), cover.Bytes(), 0644)
+	if err != nil {
+		t.Fatalf("unable to write image")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "zomg.md"), `
+---
+title: zomg is a thing
+timestamp: 2018-03-24T10:00:00Z
+updated: 2018-04-01T08:30:00Z
+image: images/cover.png
+canonical: https://elsewhere.example.org/zomg
+tags:
+  - foo
+  - bar
+---
+
+# zomg`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "plain.md"), `
+---
+title: plain
+timestamp: 2018-03-20T10:00:00Z
+twitter: guest
+---
+
+# plain`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "zomg.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, page, `<link rel="canonical" href="https://elsewhere.example.org/zomg">`)
+	mustContain(t, page, `<meta property="og:url" content="https://elsewhere.example.org/zomg" />`)
+	mustContain(t, page, `<meta property="og:type" content="article" />`)
+	mustContain(t, page, `<meta property="og:site_name" content="my blog" />`)
+	mustContain(t, page, `<meta name="twitter:card" content="summary_large_image" />`)
+	mustContain(t, page, `<meta name="twitter:site" content="@myblog" />`)
+	mustContain(t, page, `<meta name="twitter:creator" content="@jane" />`)
+	mustContain(t, page, `<meta property="og:image" content="https://example.com/images/cover.png" />`)
+	mustContain(t, page, `<meta property="og:image:width" content="1200" />`)
+	mustContain(t, page, `<meta property="og:image:height" content="630" />`)
+	mustContain(t, page, `<meta property="article:published_time" content="2018-03-24T10:00:00Z" />`)
+	mustContain(t, page, `<meta property="article:modified_time" content="2018-04-01T08:30:00Z" />`)
+	mustContain(t, page, `<meta property="article:tag" content="foo" />`)
+	mustContain(t, page, `<meta property="article:tag" content="bar" />`)
+
+	plain, err := readTmpPost(filepath.Join(tmpProject, "out", "plain.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, plain, `<link rel="canonical" href="https://example.com/plain.html">`)
+	mustContain(t, plain, `<meta name="twitter:card" content="summary" />`)
+	mustContain(t, plain, `<meta name="twitter:site" content="@myblog" />`)
+	mustContain(t, plain, `<meta name="twitter:creator" content="@guest" />`)
+	mustContain(t, plain, `<meta property="article:modified_time" content="2018-03-20T10:00:00Z" />`)
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read index")
+	}
+
+	mustContain(t, index, `<meta property="og:type" content="website" />`)
+	mustNotContain(t, index, "article:published_time")
+
+	sitemap, err := readTmpPost(filepath.Join(tmpProject, "out", "sitemap.xml"))
+	if err != nil {
+		t.Fatalf("sitemap was not generated")
+	}
+
+	mustContain(t, sitemap, "https://example.com/plain.html")
+	mustNotContain(t, sitemap, "zomg")
+}
+
+func TestSocialCards(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+title: my blog
+image: images/avatar.jpg
+social-cards: true
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "notes"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	posts := map[string]string{
+		"zomg.md": `
+---
+title: zomg is a thing
+timestamp: 2018-03-24T10:00:00Z
+---
+
+# zomg`,
+		filepath.Join("notes", "zomg.md"): `
+---
+title: zomg is another thing
+timestamp: 2018-03-20T10:00:00Z
+---
+
+# zomg`,
+		"pretty.md": `
+---
+title: pretty
+image: images/pretty.jpg
+---
+
+# pretty`,
+	}
+	for name, content := range posts {
+		err = tmpPostSetup(filepath.Join(tmpProject, "src", name), content)
+		if err != nil {
+			t.Fatalf("unable to create temporary post")
+		}
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	for _, name := range []string{"zomg.png", "zomg-2.png"} {
+		f, err := os.Open(filepath.Join(tmpProject, "out", "og", name))
+		if err != nil {
+			t.Fatalf("social card %s was not generated", name)
+		}
+
+		card, err := png.DecodeConfig(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("social card %s is not a PNG image: %v", name, err)
+		}
+		if card.Width != 1200 || card.Height != 630 {
+			t.Errorf("expected social card %s to be 1200x630, got %dx%d", name, card.Width, card.Height)
+		}
+	}
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "zomg.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, page, `<meta property="og:image" content="https://example.com/og/zomg.png" />`)
+	mustContain(t, page, `<meta property="og:image:width" content="1200" />`)
+	mustContain(t, page, `<meta property="og:image:height" content="630" />`)
+	mustContain(t, page, `<meta name="twitter:card" content="summary_large_image" />`)
+
+	other, err := readTmpPost(filepath.Join(tmpProject, "out", "notes", "zomg.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, other, `<meta property="og:image" content="https://example.com/og/zomg-2.png" />`)
+
+	pretty, err := readTmpPost(filepath.Join(tmpProject, "out", "pretty.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, pretty, `<meta property="og:image" content="https://example.com/images/pretty.jpg" />`)
+	if _, err = os.Stat(filepath.Join(tmpProject, "out", "og", "pretty.png")); err == nil {
+		t.Errorf("expected no social card for a page with an image")
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+search: full
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "generics.md"), `
+---
+title: Generics in Go
+timestamp: 2018-03-24T10:00:00Z
+tags:
+  - go
+---
+
+# Trying *generic* functions`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "secret.md"), `
+---
+title: secret
+noindex: true
+---
+
+# secret`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "search.html"), `
+<html>
+<body>
+  <script src="{{ .Root }}search.js" data-index="{{ .Root }}search-index.json"></script>
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create search layout")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "search-index.json"))
+	if err != nil {
+		t.Fatalf("search index was not generated")
+	}
+
+	mustContain(t, index, `{"title":"Generics in Go","url":"https://example.com/generics.html","date":"2018-03-24T10:00:00Z","tags":["go"],"text":"Trying generic functions"}`)
+	mustNotContain(t, index, "secret")
+	mustNotContain(t, index, `"index"`)
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "search.html"))
+	if err != nil {
+		t.Fatalf("search page was not generated")
+	}
+
+	mustContain(t, page, `data-index="https://example.com/search-index.json"`)
+
+	err = tmpPostSetup(filepath.Join(tmpProject, ".station.yml"), `
+source: src
+output: out
+site-url: https://example.com/
+search: inverted
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to update config")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	index, err = readTmpPost(filepath.Join(tmpProject, "out", "search-index.json"))
+	if err != nil {
+		t.Fatalf("search index was not generated")
+	}
+
+	mustContain(t, index, `{"title":"Generics in Go","url":"https://example.com/generics.html","date":"2018-03-24T10:00:00Z","tags":["go"]}`)
+	mustContain(t, index, `"gener":[0]`)
+	mustContain(t, index, `"try":[0]`)
+	mustNotContain(t, index, "Trying")
+}
+
+func TestRelated(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+relative-urls: true
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "notes"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "page.html"), `
+<html>
+<body>
+  {{ .Content }}
+  <ul id="related">{{ range .Related }}<li><a href="{{ .Href }}">{{ .Title }}</a></li>{{ end }}</ul>
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create page layout")
+	}
+
+	posts := map[string]string{
+		"generics.md": `
+---
+title: generics
+tags:
+  - go
+---
+
+Trying generic functions.`,
+		filepath.Join("notes", "types.md"): `
+---
+title: types
+tags:
+  - go
+---
+
+Generic types.`,
+		"cats.md": `
+---
+title: cats
+---
+
+Nothing to see here.`,
+	}
+	for name, content := range posts {
+		err = tmpPostSetup(filepath.Join(tmpProject, "src", name), content)
+		if err != nil {
+			t.Fatalf("unable to create temporary post")
+		}
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	generics, err := readTmpPost(filepath.Join(tmpProject, "out", "generics.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, generics, `<ul id="related"><li><a href="./notes/types.html">types</a></li></ul>`)
+
+	types, err := readTmpPost(filepath.Join(tmpProject, "out", "notes", "types.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, types, `<ul id="related"><li><a href="../generics.html">generics</a></li></ul>`)
+
+	cats, err := readTmpPost(filepath.Join(tmpProject, "out", "cats.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, cats, `<ul id="related"></ul>`)
+}
+
+func TestSeries(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "page.html"), `
+<html>
+<body>
+  {{ .SeriesIndex }}
+  {{ .Content }}
+  <nav id="pagination">{{ with .Prev }}<a href="{{ .Href }}" rel="prev">{{ .Title }}</a>{{ end }}{{ with .Next }}<a href="{{ .Href }}" rel="next">{{ .Title }}</a>{{ end }}</nav>
+  <p id="parts">{{ range .Series }}{{ .Title }};{{ end }}</p>
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create page layout")
+	}
+
+	posts := map[string]string{
+		"setup.md": `
+---
+title: Setting up
+timestamp: 2018-03-03T10:00:00Z
+series: Learning Go
+series_order: 1
+---
+
+Install Go.`,
+		"aside.md": `
+---
+title: An aside
+timestamp: 2018-03-04T10:00:00Z
+---
+
+Not part of it.`,
+		"types.md": `
+---
+title: Types
+timestamp: 2018-03-01T10:00:00Z
+series: Learning Go
+series_order: 2
+---
+
+Written first, read second.`,
+		"generics.md": `
+---
+title: Generics
+timestamp: 2018-03-05T10:00:00Z
+series: Learning Go
+series_order: 3
+---
+
+The last part.`,
+	}
+	for name, content := range posts {
+		err = tmpPostSetup(filepath.Join(tmpProject, "src", name), content)
+		if err != nil {
+			t.Fatalf("unable to create temporary post")
+		}
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	types, err := readTmpPost(filepath.Join(tmpProject, "out", "types.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, types, `<nav class="series">`)
+	mustContain(t, types, "<p>Part 2 of 3 in Learning Go</p>")
+	mustContain(t, types, `<li><a href="https://example.com/setup.html">Setting up</a></li>`)
+	mustContain(t, types, "<li><strong>Types</strong></li>")
+	mustContain(t, types, `<li><a href="https://example.com/generics.html">Generics</a></li>`)
+	mustContain(t, types, `<p id="parts">Setting up;Types;Generics;</p>`)
+	// the oldest post has no previous one
+	mustContain(t, types, `<nav id="pagination"><a href="https://example.com/setup.html" rel="next">Setting up</a></nav>`)
+
+	aside, err := readTmpPost(filepath.Join(tmpProject, "out", "aside.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustNotContain(t, aside, `class="series"`)
+	mustContain(t, aside, `<p id="parts"></p>`)
+	mustContain(t, aside, `<a href="https://example.com/setup.html" rel="prev">Setting up</a>`)
+	mustContain(t, aside, `<a href="https://example.com/generics.html" rel="next">Generics</a>`)
+}
+
+func TestSummaries(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "index.html"), `
+<html>
+<head>
+{{ .Headers }}
+</head>
+<body>
+  {{ range .Children }}<article><h2>{{ .Title }}</h2>{{ .Summary }}<p>{{ .WordCount }} words, {{ .ReadingTime }} min</p></article>{{ end }}
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create index layout")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "more.md"), `
+---
+title: more
+---
+
+The part shown in the index.
+
+<!--more-->
+
+The part only shown on the page.`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read index after parsing")
+	}
+
+	mustContain(t, index, "<article><h2>more</h2><p>The part shown in the index.</p><p>13 words, 1 min</p></article>")
+	mustNotContain(t, index, "only shown on the page")
+
+	more, err := readTmpPost(filepath.Join(tmpProject, "out", "more.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, more, `<meta name="description" content="The part shown in the index." />`)
+	mustContain(t, more, "The part only shown on the page.")
+
+	rss, err := readTmpPost(filepath.Join(tmpProject, "out", "index.rss"))
+	if err != nil {
+		t.Fatalf("unable to read feed after parsing")
+	}
+
+	mustContain(t, rss, "<description>The part shown in the index.</description>")
+}
+
+func TestTableOfContents(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "layouts", "page.html"), `
+<html>
+<body>
+  <aside>{{ range .TableOfContents }}<a href="{{ .Href }}">{{ .Title }}</a>({{ len .Children }});{{ end }}</aside>
+  {{ .Content }}
+</body>
+</html>
+`)
+	if err != nil {
+		t.Fatalf("unable to create page layout")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "guide.md"), `
+---
+title: guide
+toc: true
+---
+
+## Install
+
+### From source
+
+## Usage
+
+## Install`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	guide, err := readTmpPost(filepath.Join(tmpProject, "out", "guide.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, guide, `<aside><a href="#install">Install</a>(1);<a href="#usage">Usage</a>(0);<a href="#install-1">Install</a>(0);</aside>`)
+	mustContain(t, guide, `<nav class="toc"><ul><li><a href="#install">Install</a><ul><li><a href="#from-source">From source</a></li></ul></li>`)
+	mustContain(t, guide, `<h2 id="install">Install</h2>`)
+	mustContain(t, guide, `<h3 id="from-source">From source</h3>`)
+	mustContain(t, guide, `<h2 id="install-1">Install</h2>`)
+}
+
+func TestMarkdownOptions(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+markdown:
+  footnotes: true
+  hard-line-break: true
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	content := `
+Roses are red,
+violets are blue.[^poem]
+
+<span class="raw">raw</span>
+
+[^poem]: Not a real poem.`
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "site.md"), "\n---\ntitle: site\n---\n"+content)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "page.md"), `
+---
+title: page
+markdown:
+  hard-line-break: false
+  skip-html: true
+---
+`+content)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	site, err := readTmpPost(filepath.Join(tmpProject, "out", "site.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, site, "Roses are red,<br />")
+	mustContain(t, site, `<div class="footnotes">`)
+	mustContain(t, site, `<span class="raw">raw</span>`)
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "page.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustNotContain(t, page, "<br />")
+	mustContain(t, page, `<div class="footnotes">`)
+	mustNotContain(t, page, `<span class="raw">`)
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "typo.md"), `
+---
+title: typo
+markdown:
+  footnote: true
+---
+
+oops`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	stderr, err := execCommandWithOutput(tmpProject, "check")
+	if err == nil {
+		t.Fatalf("check passed with an unknown markdown option")
+	}
+
+	mustContain(t, stderr, filepath.Join("src", "typo.md")+`: markdown option "footnote" doesn't exist`)
+
+	stderr, err = execCommandWithOutput(tmpProject)
+	if err == nil {
+		t.Fatalf("build passed with an unknown markdown option")
+	}
+
+	mustContain(t, stderr, filepath.Join("src", "typo.md")+`: markdown option "footnote" doesn't exist`)
+}
+
+func TestPermalinkCollision(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+permalinks:
+  default: :year/:slug/
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	for _, section := range []string{"a", "b"} {
+		err = mkdir(filepath.Join(tmpProject, "src", section))
+		if err != nil {
+			t.Fatalf("unable to setup temp project src dir")
+		}
+
+		err = tmpPostSetup(filepath.Join(tmpProject, "src", section, "post.md"), `---
+title: post
+timestamp: 2020-01-02T03:04:05Z
+---
+
+# post`)
+		if err != nil {
+			t.Fatalf("unable to create temporary post")
+		}
+	}
+
+	stderr, err := execCommandWithOutput(tmpProject)
+	if err == nil {
+		t.Fatalf("a post overwrote another one with the same permalink")
+	}
+
+	mustContain(t, stderr, "collides with "+filepath.Join("out", "2020", "post", "index.html"))
+}
+
+func TestPrettyURLsSectionCollision(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+pretty-urls: true
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src", "a"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	for _, path := range []string{"a.md", filepath.Join("a", "post.md")} {
+		err = tmpPostSetup(filepath.Join(tmpProject, "src", path), "# post")
+		if err != nil {
+			t.Fatalf("unable to create temporary post")
+		}
+	}
+
+	stderr, err := execCommandWithOutput(tmpProject)
+	if err == nil {
+		t.Fatalf("the section index overwrote a post")
+	}
+
+	mustContain(t, stderr, "collides with "+filepath.Join("out", "a", "index.html"))
+}
+
+func TestAliases(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/blog/
+redirects:
+  - netlify
+  - nginx
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "renamed.md"), `
+---
+title: i was renamed
+aliases:
+  - original.html
+  - /2018/03/original/
+  - /old/post
+---
+
+# renamed`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	for _, path := range []string{"original.html", filepath.Join("2018", "03", "original", "index.html"), filepath.Join("old", "post", "index.html")} {
+		alias, err := readTmpPost(filepath.Join(tmpProject, "out", path))
+		if err != nil {
+			t.Fatalf("alias %s was not generated", path)
+		}
+
+		mustContain(t, alias, `<meta http-equiv="refresh" content="0; url=https://example.com/blog/renamed.html">`)
+		mustContain(t, alias, `<link rel="canonical" href="https://example.com/blog/renamed.html">`)
+	}
+
+	netlify, err := readTmpPost(filepath.Join(tmpProject, "out", "_redirects"))
+	if err != nil {
+		t.Fatalf("netlify redirects were not generated")
+	}
+
+	mustContain(t, netlify, "/blog/2018/03/original/ /blog/renamed.html 301\n/blog/old/post /blog/renamed.html 301\n/blog/original.html /blog/renamed.html 301\n")
+
+	nginx, err := readTmpPost(filepath.Join(tmpProject, "out", "redirects.map"))
+	if err != nil {
+		t.Fatalf("nginx redirects were not generated")
+	}
+
+	mustContain(t, nginx, "/blog/original.html /blog/renamed.html;\n")
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "original.md"), `
+# i'm back`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	stderr, err := execCommandWithOutput(tmpProject)
+	if err == nil {
+		t.Fatalf("alias overwrote an existing page")
+	}
+
+	mustContain(t, stderr, "alias original.html of "+filepath.Join("src", "renamed.md")+" collides with "+filepath.Join("out", "original.html"))
+}
+
+func TestAliasCollisions(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	for _, alias := range []string{"css/style.css", "sitemap.xml", "robots.txt"} {
+		tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+site-url: https://example.com/
+assets:
+  css:
+    - style.css`)
+		if err != nil {
+			t.Fatalf("unable to setup temporary working dir")
+		}
+		defer os.RemoveAll(tmpProject)
+
+		err = mkdir(filepath.Join(tmpProject, "src"))
+		if err != nil {
+			t.Fatalf("unable to setup temp project src dir")
+		}
+
+		err = tmpPostSetup(filepath.Join(tmpProject, "src", "post.md"), `---
+title: post
+aliases:
+  - `+alias+`
+---
+
+# post`)
+		if err != nil {
+			t.Fatalf("unable to create temporary post")
+		}
+
+		stderr, err := execCommandWithOutput(tmpProject)
+		if err == nil {
+			t.Fatalf("alias overwrote %s", alias)
+		}
+
+		mustContain(t, stderr, "alias "+alias+" of "+filepath.Join("src", "post.md")+" collides with "+filepath.Join("out", filepath.FromSlash(alias)))
+	}
+}
+
 func TestGenerateTags(t *testing.T) {
 	if os.Getenv("BE_STATIONERY") == "1" {
 		main()
 		return
 	}
 
-	tmpProject, err := tmpProjectSetup(`
-source: src
-output: out
-assets:
-  css:
-    - style.css`)
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+assets:
+  css:
+    - style.css`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	tmpOut := filepath.Join(tmpProject, "out")
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "zomg.md"), `
+---
+title: zomg is a thing
+tags:
+  - foo
+  - bar
+---
+
+# zomg
+{{ .Timestamp "2018-03-24T12:43:03" }}
+
+this is my temp post!`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "two.md"), `
+---
+title: my second post
+tags:
+  - bar
+---
+
+# two
+{{ .Timestamp "2018-03-24T12:43:03" }}
+
+wow, so easy!`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "tag", "bar.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, index, fmt.Sprintf(`<a href="%s/zomg.html">zomg is a thing</a>`, tmpOut))
+	mustContain(t, index, fmt.Sprintf(`<a href="%s/two.html">my second post</a>`, tmpOut))
+	mustContain(t, index, `
+<html>
+<head>`)
+	mustContain(t, index, `<div id="index">`)
+}
+
+func TestTitles(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+title: my blog
+assets:`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	tmpOut := filepath.Join(tmpProject, "out")
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "one.md"), `
+---
+title: first!
+---
+
+# one
+
+this is one!`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "two.md"), `
+---
+title: second!
+---
+
+# two
+
+this is two!`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "three.md"), `
+# three
+
+no title!`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, index, fmt.Sprintf(`<a href="%s/one.html">first!</a>`, tmpOut))
+	mustContain(t, index, fmt.Sprintf(`<a href="%s/three.html">three</a>`, tmpOut))
+	mustContain(t, index, `
+<html>
+<head>
+<title>my blog</title>`)
+
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "three.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, page, `<title>three</title>`)
+}
+
+func TestConfigIndexMetaData(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+title: my blog
+description: my default description
+twitter: aedipamoss
+image: images/avatar.jpg`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	tmpOut := filepath.Join(tmpProject, "out")
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "config.md"), `
+---
+title: config inherited defaults!
+tags:
+  - mytag
+---
+
+this is config!`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, index, fmt.Sprintf(`<meta name="description" content="%s" />`, "my default description"))
+	mustContain(t, index, fmt.Sprintf(`<meta property="og:description" content="%s" />`, "my default description"))
+	mustContain(t, index, fmt.Sprintf(`<meta property="og:title" content="%s" />`, "my blog"))
+	mustContain(t, index, fmt.Sprintf(`<meta property="og:image" content="%s" />`, filepath.Join(tmpOut, "images", "avatar.jpg")))
+	mustContain(t, index, `<meta name="twitter:card" content="summary_large_image" />`)
+	mustContain(t, index, `<meta name="twitter:creator" content="@aedipamoss" />`)
+	mustContain(t, index, `<meta name="twitter:site" content="@aedipamoss" />`)
+
+	tag, err := readTmpPost(filepath.Join(tmpProject, "out", "tag", "mytag.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, tag, fmt.Sprintf(`<meta property="og:title" content="%s" />`, "my blog"))
+	mustContain(t, tag, fmt.Sprintf(`<meta property="og:image" content="%s" />`, filepath.Join(tmpOut, "images", "avatar.jpg")))
+	mustContain(t, tag, `<meta name="twitter:card" content="summary_large_image" />`)
+	mustContain(t, tag, `<meta name="twitter:creator" content="@aedipamoss" />`)
+	mustContain(t, tag, `<meta name="twitter:site" content="@aedipamoss" />`)
+}
+
+func TestPageMetaData(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+title: my blog
+description: my default description
+twitter: aedipamoss
+image: images/avatar.jpg`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	tmpOut := filepath.Join(tmpProject, "out")
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "config.md"), `
+---
+title: config inherited defaults!
+tags:
+  - mytag
+---
+
+this is config!`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "empty.md"), `
+---
+title: nothing to summarize
+---
+`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	config, err := readTmpPost(filepath.Join(tmpProject, "out", "config.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	// the summary of a page describes it better than the site
+	mustContain(t, config, fmt.Sprintf(`<meta name="description" content="%s" />`, "this is config!"))
+	mustContain(t, config, fmt.Sprintf(`<meta property="og:description" content="%s" />`, "this is config!"))
+	mustContain(t, config, fmt.Sprintf(`<meta property="og:title" content="%s" />`, "config inherited defaults!"))
+	mustContain(t, config, fmt.Sprintf(`<meta property="og:image" content="%s" />`, filepath.Join(tmpOut, "images", "avatar.jpg")))
+	empty, err := readTmpPost(filepath.Join(tmpProject, "out", "empty.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, empty, fmt.Sprintf(`<meta name="description" content="%s" />`, "my default description"))
+
+	// without a site URL there's no absolute URL to point to
+	mustNotContain(t, config, `rel="canonical"`)
+	mustNotContain(t, config, `og:url`)
+	mustContain(t, config, `<meta name="twitter:card" content="summary_large_image" />`)
+	mustContain(t, config, `<meta name="twitter:creator" content="@aedipamoss" />`)
+	mustContain(t, config, `<meta name="twitter:site" content="@aedipamoss" />`)
+}
+
+func TestOverideMetaData(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+title: my blog
+description: my default description
+twitter: aedipamoss
+image: images/avatar.jpg`)
+	if err != nil {
+		t.Fatalf("unable to setup temporary working dir")
+	}
+	defer os.RemoveAll(tmpProject)
+
+	tmpOut := filepath.Join(tmpProject, "out")
+
+	err = mkdir(filepath.Join(tmpProject, "src"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project src dir")
+	}
+
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "overide.md"), `
+---
+title: config overridden!
+twitter: forgetme
+image: images/zomg.jpg
+description: description overridden!
+---
+
+this is overridden!`)
+	if err != nil {
+		t.Fatalf("unable to create temporary post")
+	}
+
+	err = execCommandWithProject(tmpProject)
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	overridden, err := readTmpPost(filepath.Join(tmpProject, "out", "overide.html"))
+	if err != nil {
+		t.Fatalf("unable to read temporary post after parsing")
+	}
+
+	mustContain(t, overridden, fmt.Sprintf(`<meta name="description" content="%s" />`, "description overridden!"))
+	mustContain(t, overridden, fmt.Sprintf(`<meta property="og:description" content="%s" />`, "description overridden!"))
+	mustContain(t, overridden, fmt.Sprintf(`<meta property="og:title" content="%s" />`, "config overridden!"))
+	mustContain(t, overridden, fmt.Sprintf(`<meta property="og:image" content="%s" />`, filepath.Join(tmpOut, "images", "zomg.jpg")))
+	mustContain(t, overridden, `<meta name="twitter:card" content="summary_large_image" />`)
+	mustContain(t, overridden, `<meta name="twitter:creator" content="@forgetme" />`)
+	mustContain(t, overridden, `<meta name="twitter:site" content="@aedipamoss" />`)
+}
+
+func TestInit(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := ioutil.TempDir("", "stationery")
 	if err != nil {
 		t.Fatalf("unable to setup temporary working dir")
 	}
 	defer os.RemoveAll(tmpProject)
 
-	tmpOut := filepath.Join(tmpProject, "out")
-
-	err = mkdir(filepath.Join(tmpProject, "src"))
+	err = execCommandWithProject(tmpProject, "init", "-y", "-title", "my blog", "-site-url", "https://example.com/")
 	if err != nil {
-		t.Fatalf("unable to setup temp project src dir")
+		t.Fatalf("command finished with error %v", err)
 	}
 
-	err = tmpPostSetup(filepath.Join(tmpProject, "src", "zomg.md"), `
----
-title: zomg is a thing
-tags:
-  - foo
-  - bar
----
+	config, err := readTmpPost(filepath.Join(tmpProject, ".station.yml"))
+	if err != nil {
+		t.Fatalf("unable to read generated config")
+	}
 
-# zomg
-{{ .Timestamp "2018-03-24T12:43:03" }}
+	mustContain(t, config, "title: my blog")
+	mustContain(t, config, "site-url: https://example.com/")
+	mustContain(t, config, "source: src")
 
-this is my temp post!`)
+	gitignore, err := readTmpPost(filepath.Join(tmpProject, ".gitignore"))
 	if err != nil {
-		t.Fatalf("unable to create temporary post")
+		t.Fatalf("unable to read generated .gitignore")
 	}
 
-	err = tmpPostSetup(filepath.Join(tmpProject, "src", "two.md"), `
----
-title: my second post
-tags:
-  - bar
----
-
-# two
-{{ .Timestamp "2018-03-24T12:43:03" }}
+	mustContain(t, gitignore, "/out/")
 
-wow, so easy!`)
+	err = execCommandWithProject(tmpProject)
 	if err != nil {
-		t.Fatalf("unable to create temporary post")
+		t.Fatalf("unable to build initialized project %v", err)
 	}
 
-	err = execCommandWithProject(tmpProject)
+	page, err := readTmpPost(filepath.Join(tmpProject, "out", "hello-world.html"))
 	if err != nil {
-		t.Fatalf("command finished with error %v", err)
+		t.Fatalf("example post was not generated")
 	}
 
-	index, err := readTmpPost(filepath.Join(tmpProject, "out", "tag", "bar.html"))
+	mustContain(t, page, "<title>Hello, world</title>")
+
+	err = execCommandWithProject(tmpProject, "init", "-y", "-title", "overwritten")
+	if err == nil {
+		t.Fatalf("init overwrote an existing project")
+	}
+
+	config, err = readTmpPost(filepath.Join(tmpProject, ".station.yml"))
 	if err != nil {
-		t.Fatalf("unable to read temporary post after parsing")
+		t.Fatalf("unable to read generated config")
 	}
 
-	mustContain(t, index, fmt.Sprintf(`<a href="%s/zomg.html">zomg is a thing</a>`, tmpOut))
-	mustContain(t, index, fmt.Sprintf(`<a href="%s/two.html">my second post</a>`, tmpOut))
-	mustContain(t, index, `
-<html>
-<head>`)
-	mustContain(t, index, `<div id="index">`)
+	mustNotContain(t, config, "overwritten")
 }
 
-func TestTitles(t *testing.T) {
+func TestNewPost(t *testing.T) {
 	if os.Getenv("BE_STATIONERY") == "1" {
 		main()
 		return
@@ -278,78 +2534,107 @@ func TestTitles(t *testing.T) {
 	tmpProject, err := tmpProjectSetup(`
 source: src
 output: out
-title: my blog
+timezone: Asia/Tokyo
 assets:`)
 	if err != nil {
 		t.Fatalf("unable to setup temporary working dir")
 	}
 	defer os.RemoveAll(tmpProject)
 
-	tmpOut := filepath.Join(tmpProject, "out")
-
 	err = mkdir(filepath.Join(tmpProject, "src"))
 	if err != nil {
 		t.Fatalf("unable to setup temp project src dir")
 	}
 
-	err = tmpPostSetup(filepath.Join(tmpProject, "src", "one.md"), `
----
-title: first!
----
-
-# one
+	err = execCommandWithProject(tmpProject, "new", "post", "My Title: the sequel!")
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
 
-this is one!`)
+	post, err := readTmpPost(filepath.Join(tmpProject, "src", "my-title-the-sequel.md"))
 	if err != nil {
-		t.Fatalf("unable to create temporary post")
+		t.Fatalf("new post was not created")
 	}
 
-	err = tmpPostSetup(filepath.Join(tmpProject, "src", "two.md"), `
----
-title: second!
----
+	mustContain(t, post, `title: 'My Title: the sequel!'`)
+	mustContain(t, post, "+09:00\n")
+	mustContain(t, post, "tags: []")
+	mustContain(t, post, "draft: true")
 
-# two
+	err = execCommandWithProject(tmpProject, "new", "post", "My Title: the sequel!")
+	if err == nil {
+		t.Fatalf("new overwrote an existing post")
+	}
 
-this is two!`)
+	err = execCommandWithProject(tmpProject)
 	if err != nil {
-		t.Fatalf("unable to create temporary post")
+		t.Fatalf("command finished with error %v", err)
 	}
 
-	err = tmpPostSetup(filepath.Join(tmpProject, "src", "three.md"), `
-# three
+	if _, err = os.Stat(filepath.Join(tmpProject, "out", "my-title-the-sequel.html")); err == nil {
+		t.Fatalf("draft was generated")
+	}
+}
 
-no title!`)
+func TestNewFromArchetype(t *testing.T) {
+	if os.Getenv("BE_STATIONERY") == "1" {
+		main()
+		return
+	}
+
+	tmpProject, err := tmpProjectSetup(`
+source: src
+output: out
+assets:`)
 	if err != nil {
-		t.Fatalf("unable to create temporary post")
+		t.Fatalf("unable to setup temporary working dir")
 	}
+	defer os.RemoveAll(tmpProject)
 
-	err = execCommandWithProject(tmpProject)
+	err = mkdir(filepath.Join(tmpProject, "src"))
 	if err != nil {
-		t.Fatalf("command finished with error %v", err)
+		t.Fatalf("unable to setup temp project src dir")
 	}
 
-	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	err = mkdir(filepath.Join(tmpProject, "archetypes"))
 	if err != nil {
-		t.Fatalf("unable to read temporary post after parsing")
+		t.Fatalf("unable to setup temp project archetypes dir")
 	}
 
-	mustContain(t, index, fmt.Sprintf(`<a href="%s/one.html">first!</a>`, tmpOut))
-	mustContain(t, index, fmt.Sprintf(`<a href="%s/three.html">three</a>`, tmpOut))
-	mustContain(t, index, `
-<html>
-<head>
-<title>my blog</title>`)
+	err = tmpPostSetup(filepath.Join(tmpProject, "archetypes", "link.md"), `---
+title: {{ yaml .Title }}
+timestamp: {{ .Timestamp }}
+tags:
+  - {{ .Kind }}
+---
 
-	page, err := readTmpPost(filepath.Join(tmpProject, "out", "three.html"))
+# {{ .Title }} ({{ .Slug }})
+`)
 	if err != nil {
-		t.Fatalf("unable to read temporary post after parsing")
+		t.Fatalf("unable to create temporary archetype")
 	}
 
-	mustContain(t, page, `<title>three</title>`)
+	err = execCommandWithProject(tmpProject, "new", "link", "Cool Site")
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	link, err := readTmpPost(filepath.Join(tmpProject, "src", "cool-site.md"))
+	if err != nil {
+		t.Fatalf("new link was not created")
+	}
+
+	mustContain(t, link, "title: Cool Site")
+	mustContain(t, link, "  - link")
+	mustContain(t, link, "# Cool Site (cool-site)")
+
+	err = execCommandWithProject(tmpProject, "new", "missing", "Nope")
+	if err == nil {
+		t.Fatalf("new created a file without an archetype")
+	}
 }
 
-func TestConfigIndexMetaData(t *testing.T) {
+func TestPublish(t *testing.T) {
 	if os.Getenv("BE_STATIONERY") == "1" {
 		main()
 		return
@@ -358,65 +2643,76 @@ func TestConfigIndexMetaData(t *testing.T) {
 	tmpProject, err := tmpProjectSetup(`
 source: src
 output: out
-title: my blog
-description: my default description
-twitter: aedipamoss
-image: images/avatar.jpg`)
+timezone: Asia/Tokyo
+assets:`)
 	if err != nil {
 		t.Fatalf("unable to setup temporary working dir")
 	}
 	defer os.RemoveAll(tmpProject)
 
-	tmpOut := filepath.Join(tmpProject, "out")
-
 	err = mkdir(filepath.Join(tmpProject, "src"))
 	if err != nil {
 		t.Fatalf("unable to setup temp project src dir")
 	}
 
-	err = tmpPostSetup(filepath.Join(tmpProject, "src", "config.md"), `
----
-title: config inherited defaults!
-tags:
-  - mytag
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "wip.md"), `---
+title:  "work: in progress"
+draft: true
+tags: [foo, bar]
 ---
 
-this is config!`)
+# wip
+{{ .Timestamp "2018-03-24T12:43:03" }}
+
+almost done!`)
 	if err != nil {
 		t.Fatalf("unable to create temporary post")
 	}
 
-	err = execCommandWithProject(tmpProject)
+	err = execCommandWithProject(tmpProject, "publish", "-rename", filepath.Join("src", "wip.md"))
 	if err != nil {
 		t.Fatalf("command finished with error %v", err)
 	}
 
-	index, err := readTmpPost(filepath.Join(tmpProject, "out", "index.html"))
+	matches, err := filepath.Glob(filepath.Join(tmpProject, "src", "*-wip.md"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("published post was not renamed")
+	}
+
+	if _, err = os.Stat(filepath.Join(tmpProject, "src", "wip.md")); err == nil {
+		t.Fatalf("draft was left behind after renaming")
+	}
+
+	post, err := readTmpPost(matches[0])
 	if err != nil {
-		t.Fatalf("unable to read temporary post after parsing")
+		t.Fatalf("unable to read published post")
 	}
 
-	mustContain(t, index, fmt.Sprintf(`<meta name="description" content="%s" />`, "my default description"))
-	mustContain(t, index, fmt.Sprintf(`<meta property="og:description" content="%s" />`, "my default description"))
-	mustContain(t, index, fmt.Sprintf(`<meta property="og:title" content="%s" />`, "my blog"))
-	mustContain(t, index, fmt.Sprintf(`<meta property="og:image" content="%s" />`, filepath.Join(tmpOut, "images", "avatar.jpg")))
-	mustContain(t, index, `<meta name="twitter:card" content="summary" />`)
-	mustContain(t, index, `<meta name="twitter:creator" content="@aedipamoss" />`)
-	mustContain(t, index, `<meta name="twitter:site" content="@aedipamoss" />`)
+	mustContain(t, post, `---
+title:  "work: in progress"
+draft: false
+tags: [foo, bar]
+timestamp: `)
+	mustContain(t, post, `+09:00
+---
 
-	tag, err := readTmpPost(filepath.Join(tmpProject, "out", "tag", "mytag.html"))
+# wip
+{{ .Timestamp "2018-03-24T12:43:03" }}
+
+almost done!`)
+
+	err = execCommandWithProject(tmpProject)
 	if err != nil {
-		t.Fatalf("unable to read temporary post after parsing")
+		t.Fatalf("command finished with error %v", err)
 	}
 
-	mustContain(t, tag, fmt.Sprintf(`<meta property="og:title" content="%s" />`, "my blog"))
-	mustContain(t, tag, fmt.Sprintf(`<meta property="og:image" content="%s" />`, filepath.Join(tmpOut, "images", "avatar.jpg")))
-	mustContain(t, tag, `<meta name="twitter:card" content="summary" />`)
-	mustContain(t, tag, `<meta name="twitter:creator" content="@aedipamoss" />`)
-	mustContain(t, tag, `<meta name="twitter:site" content="@aedipamoss" />`)
+	slug := strings.TrimSuffix(filepath.Base(matches[0]), ".md")
+	if _, err = os.Stat(filepath.Join(tmpProject, "out", slug+".html")); err != nil {
+		t.Fatalf("published post was not generated")
+	}
 }
 
-func TestPageMetaData(t *testing.T) {
+func TestGlobalFlags(t *testing.T) {
 	if os.Getenv("BE_STATIONERY") == "1" {
 		main()
 		return
@@ -425,54 +2721,82 @@ func TestPageMetaData(t *testing.T) {
 	tmpProject, err := tmpProjectSetup(`
 source: src
 output: out
-title: my blog
-description: my default description
-twitter: aedipamoss
-image: images/avatar.jpg`)
+assets:`)
 	if err != nil {
 		t.Fatalf("unable to setup temporary working dir")
 	}
 	defer os.RemoveAll(tmpProject)
 
-	tmpOut := filepath.Join(tmpProject, "out")
+	err = mkdir(filepath.Join(tmpProject, "config"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project config dir")
+	}
 
-	err = mkdir(filepath.Join(tmpProject, "src"))
+	err = tmpPostSetup(filepath.Join(tmpProject, "config", "site.yml"), `
+source: posts
+output: public
+assets:`)
 	if err != nil {
-		t.Fatalf("unable to setup temp project src dir")
+		t.Fatalf("unable to create temporary config")
 	}
 
-	err = tmpPostSetup(filepath.Join(tmpProject, "src", "config.md"), `
----
-title: config inherited defaults!
-tags:
-  - mytag
----
+	err = mkdir(filepath.Join(tmpProject, "posts"))
+	if err != nil {
+		t.Fatalf("unable to setup temp project posts dir")
+	}
 
-this is config!`)
+	err = tmpPostSetup(filepath.Join(tmpProject, "posts", "zomg.md"), `
+# zomg
+
+this is my temp post!`)
 	if err != nil {
 		t.Fatalf("unable to create temporary post")
 	}
 
-	err = execCommandWithProject(tmpProject)
+	err = execCommandWithProject(tmpProject, "-quiet", "-config", filepath.Join("config", "site.yml"), "build")
 	if err != nil {
 		t.Fatalf("command finished with error %v", err)
 	}
 
-	config, err := readTmpPost(filepath.Join(tmpProject, "out", "config.html"))
+	if _, err = os.Stat(filepath.Join(tmpProject, "public", "zomg.html")); err != nil {
+		t.Fatalf("page was not generated using -config")
+	}
+
+	err = execCommandWithProject(tmpProject, "build", "-config", filepath.Join("config", "site.yml"), "-output", "elsewhere")
 	if err != nil {
-		t.Fatalf("unable to read temporary post after parsing")
+		t.Fatalf("command finished with error %v", err)
 	}
 
-	mustContain(t, config, fmt.Sprintf(`<meta name="description" content="%s" />`, "my default description"))
-	mustContain(t, config, fmt.Sprintf(`<meta property="og:description" content="%s" />`, "my default description"))
-	mustContain(t, config, fmt.Sprintf(`<meta property="og:title" content="%s" />`, "config inherited defaults!"))
-	mustContain(t, config, fmt.Sprintf(`<meta property="og:image" content="%s" />`, filepath.Join(tmpOut, "images", "avatar.jpg")))
-	mustContain(t, config, `<meta name="twitter:card" content="summary" />`)
-	mustContain(t, config, `<meta name="twitter:creator" content="@aedipamoss" />`)
-	mustContain(t, config, `<meta name="twitter:site" content="@aedipamoss" />`)
+	if _, err = os.Stat(filepath.Join(tmpProject, "elsewhere", "zomg.html")); err != nil {
+		t.Fatalf("page was not generated using -output")
+	}
+
+	err = execCommandWithProject(tmpProject, "clean", "-output", "elsewhere")
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	if _, err = os.Stat(filepath.Join(tmpProject, "elsewhere")); err == nil {
+		t.Fatalf("output was not removed by clean")
+	}
+
+	err = execCommandWithProject(tmpProject, "clean", "-output", ".")
+	if err == nil {
+		t.Fatalf("clean removed the project directory")
+	}
+
+	err = execCommandWithProject(tmpProject, "check", "-source", "posts")
+	if err != nil {
+		t.Fatalf("command finished with error %v", err)
+	}
+
+	err = execCommandWithProject(tmpProject, "check", "-source", "missing")
+	if err == nil {
+		t.Fatalf("check passed with a missing source")
+	}
 }
 
-func TestOverideMetaData(t *testing.T) {
+func TestCheck(t *testing.T) {
 	if os.Getenv("BE_STATIONERY") == "1" {
 		main()
 		return
@@ -481,52 +2805,61 @@ func TestOverideMetaData(t *testing.T) {
 	tmpProject, err := tmpProjectSetup(`
 source: src
 output: out
-title: my blog
-description: my default description
-twitter: aedipamoss
-image: images/avatar.jpg`)
+titel: my blog
+site-url: example.com/blog
+assets:
+  css:
+    - style.css
+    - missing.css`)
 	if err != nil {
 		t.Fatalf("unable to setup temporary working dir")
 	}
 	defer os.RemoveAll(tmpProject)
 
-	tmpOut := filepath.Join(tmpProject, "out")
-
 	err = mkdir(filepath.Join(tmpProject, "src"))
 	if err != nil {
 		t.Fatalf("unable to setup temp project src dir")
 	}
 
-	err = tmpPostSetup(filepath.Join(tmpProject, "src", "overide.md"), `
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "ok.md"), `
 ---
-title: config overridden!
-twitter: forgetme
-image: images/zomg.jpg
-description: description overridden!
+title: all good
+timestamp: 2018-03-24T12:43:03Z
 ---
 
-this is overridden!`)
+fine!`)
 	if err != nil {
 		t.Fatalf("unable to create temporary post")
 	}
 
-	err = execCommandWithProject(tmpProject)
+	err = tmpPostSetup(filepath.Join(tmpProject, "src", "broken.md"), `
+---
+title: broken
+tilte: oops
+timestamp: 2018-03-24
+---
+
+broken!`)
 	if err != nil {
-		t.Fatalf("command finished with error %v", err)
+		t.Fatalf("unable to create temporary post")
 	}
 
-	overridden, err := readTmpPost(filepath.Join(tmpProject, "out", "overide.html"))
-	if err != nil {
-		t.Fatalf("unable to read temporary post after parsing")
+	stderr, err := execCommandWithOutput(tmpProject, "check")
+	if err == nil {
+		t.Fatalf("check passed with an invalid project")
 	}
 
-	mustContain(t, overridden, fmt.Sprintf(`<meta name="description" content="%s" />`, "description overridden!"))
-	mustContain(t, overridden, fmt.Sprintf(`<meta property="og:description" content="%s" />`, "description overridden!"))
-	mustContain(t, overridden, fmt.Sprintf(`<meta property="og:title" content="%s" />`, "config overridden!"))
-	mustContain(t, overridden, fmt.Sprintf(`<meta property="og:image" content="%s" />`, filepath.Join(tmpOut, "images", "zomg.jpg")))
-	mustContain(t, overridden, `<meta name="twitter:card" content="summary" />`)
-	mustContain(t, overridden, `<meta name="twitter:creator" content="@forgetme" />`)
-	mustContain(t, overridden, `<meta name="twitter:site" content="@forgetme" />`)
+	mustContain(t, stderr, `.station.yml: line 4: unknown key "titel"`)
+	mustContain(t, stderr, `site-url "example.com/blog" must be an absolute URL`)
+	mustContain(t, stderr, filepath.Join("assets", "css", "missing.css")+" does not exist")
+	mustContain(t, stderr, filepath.Join("src", "broken.md")+`: line 4: unknown key "tilte"`)
+	mustContain(t, stderr, `timestamp "2018-03-24" is not in RFC3339 format`)
+	mustNotContain(t, stderr, "ok.md")
+	mustNotContain(t, stderr, "style.css")
+
+	if _, err = os.Stat(filepath.Join(tmpProject, "out")); err == nil {
+		t.Fatalf("check wrote output")
+	}
 }
 
 func mustContain(t *testing.T, page string, expected string) {
@@ -541,22 +2874,28 @@ func mustNotContain(t *testing.T, page string, unexpected string) {
 	}
 }
 
-func execCommandWithProject(tmpProject string) error {
+func execCommandWithProject(tmpProject string, args ...string) error {
+	stderr, err := execCommandWithOutput(tmpProject, args...)
+	if err != nil {
+		fmt.Printf("%s: \n%s\n\n", err, stderr)
+	}
+
+	return err
+}
+
+// Run the command like execCommandWithProject, returning what it printed to stderr.
+func execCommandWithOutput(tmpProject string, args ...string) (string, error) {
 	var out bytes.Buffer
 	var stderr bytes.Buffer
 
-	cmd := exec.Command(os.Args[0], "-test.run=TestStationery")
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=TestStationery", "--"}, args...)...)
 	cmd.Stdout = &out
 	cmd.Stderr = &stderr
 	cmd.Dir = tmpProject
 	cmd.Env = append(os.Environ(), "BE_STATIONERY=1")
 	err := cmd.Run()
-	if err != nil {
-		fmt.Printf("%s: \n%s\n\n", err, stderr.String())
-		return err
-	}
 
-	return nil
+	return stderr.String(), err
 }
 
 func readTmpPost(path string) (string, error) {
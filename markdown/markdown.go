@@ -0,0 +1,148 @@
+// Package markdown turns markdown into HTML, with options named in the config and front-matter.
+//
+// Options are the extensions of the blackfriday parser and the flags of its HTML renderer,
+// named like the constants of blackfriday in kebab-case, so `Footnotes` is "footnotes".
+// They start from blackfriday's CommonExtensions and CommonHTMLFlags.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	blackfriday "gopkg.in/russross/blackfriday.v2"
+)
+
+// Options turns extensions and flags on or off by name, like {"footnotes": true, "smartypants": false}.
+type Options map[string]bool
+
+var extensions = map[string]blackfriday.Extensions{
+	"autolink":                   blackfriday.Autolink,
+	"backslash-line-break":       blackfriday.BackslashLineBreak,
+	"definition-lists":           blackfriday.DefinitionLists,
+	"fenced-code":                blackfriday.FencedCode,
+	"footnotes":                  blackfriday.Footnotes,
+	"hard-line-break":            blackfriday.HardLineBreak,
+	"heading-ids":                blackfriday.HeadingIDs,
+	"lax-html-blocks":            blackfriday.LaxHTMLBlocks,
+	"no-empty-line-before-block": blackfriday.NoEmptyLineBeforeBlock,
+	"no-intra-emphasis":          blackfriday.NoIntraEmphasis,
+	"space-headings":             blackfriday.SpaceHeadings,
+	"strikethrough":              blackfriday.Strikethrough,
+	"tab-size-eight":             blackfriday.TabSizeEight,
+	"tables":                     blackfriday.Tables,
+	"titleblock":                 blackfriday.Titleblock,
+}
+
+var flags = map[string]blackfriday.HTMLFlags{
+	"footnote-return-links":     blackfriday.FootnoteReturnLinks,
+	"href-target-blank":         blackfriday.HrefTargetBlank,
+	"nofollow-links":            blackfriday.NofollowLinks,
+	"noreferrer-links":          blackfriday.NoreferrerLinks,
+	"safelink":                  blackfriday.Safelink,
+	"skip-html":                 blackfriday.SkipHTML,
+	"skip-images":               blackfriday.SkipImages,
+	"skip-links":                blackfriday.SkipLinks,
+	"smartypants":               blackfriday.Smartypants,
+	"smartypants-angled-quotes": blackfriday.SmartypantsAngledQuotes,
+	"smartypants-dashes":        blackfriday.SmartypantsDashes,
+	"smartypants-fractions":     blackfriday.SmartypantsFractions,
+	"smartypants-latex-dashes":  blackfriday.SmartypantsLatexDashes,
+	"smartypants-quotes-nbsp":   blackfriday.SmartypantsQuotesNBSP,
+	"use-xhtml":                 blackfriday.UseXHTML,
+}
+
+// Names returns the name of every option, sorted.
+func Names() []string {
+	var names []string
+	for name := range extensions {
+		names = append(names, name)
+	}
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Check reports every option which doesn't exist, sorted by name.
+func (options Options) Check() []error {
+	var unknown []string
+	for name := range options {
+		_, extension := extensions[name]
+		_, flag := flags[name]
+		if !extension && !flag {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	var errs []error
+	for _, name := range unknown {
+		errs = append(errs, fmt.Errorf("markdown option %q doesn't exist", name))
+	}
+
+	return errs
+}
+
+// Merge returns options with the ones of override set over them, neither is changed.
+func (options Options) Merge(override Options) Options {
+	merged := make(Options, len(options)+len(override))
+	for name, on := range options {
+		merged[name] = on
+	}
+	for name, on := range override {
+		merged[name] = on
+	}
+
+	return merged
+}
+
+// Extensions of the parser turned on by options.
+// Headings always get an ID, for the table of contents of pages.
+func (options Options) Extensions() blackfriday.Extensions {
+	set := blackfriday.CommonExtensions | blackfriday.AutoHeadingIDs
+	for name, on := range options {
+		if on {
+			set |= extensions[name]
+		} else {
+			set &^= extensions[name]
+		}
+	}
+
+	return set
+}
+
+// Flags of the HTML renderer turned on by options.
+func (options Options) Flags() blackfriday.HTMLFlags {
+	set := blackfriday.CommonHTMLFlags
+	for name, on := range options {
+		if on {
+			set |= flags[name]
+		} else {
+			set &^= flags[name]
+		}
+	}
+
+	return set
+}
+
+// Parse returns the syntax tree of input, to change it before rendering.
+func (options Options) Parse(input []byte) *blackfriday.Node {
+	return blackfriday.New(blackfriday.WithExtensions(options.Extensions())).Parse(input)
+}
+
+// Render writes the HTML of ast, like blackfriday.Run does.
+func (options Options) Render(ast *blackfriday.Node) []byte {
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{Flags: options.Flags()})
+
+	var buf bytes.Buffer
+	renderer.RenderHeader(&buf, ast)
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		return renderer.RenderNode(&buf, node, entering)
+	})
+	renderer.RenderFooter(&buf, ast)
+
+	return buf.Bytes()
+}
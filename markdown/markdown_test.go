@@ -0,0 +1,78 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	blackfriday "gopkg.in/russross/blackfriday.v2"
+)
+
+func TestCheck(t *testing.T) {
+	options := Options{"footnotes": true, "skip-html": true, "footnote": true, "raw-html": false}
+
+	errs := options.Check()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 unknown options, got %v", errs)
+	}
+	if !strings.Contains(errs[0].Error(), `"footnote"`) || !strings.Contains(errs[1].Error(), `"raw-html"`) {
+		t.Errorf("unknown options weren't reported in order: %v", errs)
+	}
+
+	for _, name := range Names() {
+		if errs := (Options{name: true}).Check(); len(errs) > 0 {
+			t.Errorf("option %q from Names() was reported as unknown", name)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	site := Options{"footnotes": true, "smartypants": true}
+	page := Options{"smartypants": false}
+
+	merged := site.Merge(page)
+	if !merged["footnotes"] || merged["smartypants"] {
+		t.Errorf("page options weren't set over the site ones: %v", merged)
+	}
+	if !site["smartypants"] {
+		t.Errorf("Merge() changed the site options")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	var none Options
+	if none.Extensions() != blackfriday.CommonExtensions|blackfriday.AutoHeadingIDs {
+		t.Errorf("extensions without options aren't the common ones")
+	}
+	if none.Flags() != blackfriday.CommonHTMLFlags {
+		t.Errorf("flags without options aren't the common ones")
+	}
+
+	options := Options{"footnotes": true, "tables": false, "skip-html": true, "smartypants": false}
+	if options.Extensions()&blackfriday.Footnotes == 0 || options.Extensions()&blackfriday.Tables != 0 {
+		t.Errorf("extensions weren't turned on and off: %b", options.Extensions())
+	}
+	if options.Flags()&blackfriday.SkipHTML == 0 || options.Flags()&blackfriday.Smartypants != 0 {
+		t.Errorf("flags weren't turned on and off: %b", options.Flags())
+	}
+}
+
+func TestRender(t *testing.T) {
+	input := []byte("Hello<sup>1</sup>[^note] -- world\n\n[^note]: A footnote.\n")
+
+	html := string(Options{}.Render(Options{}.Parse(input)))
+	if !strings.Contains(html, "<sup>1</sup>") || !strings.Contains(html, "&ndash;") {
+		t.Errorf("common options didn't keep raw HTML and smart dashes: %q", html)
+	}
+	if strings.Contains(html, "footnotes") {
+		t.Errorf("footnotes were rendered without the extension: %q", html)
+	}
+
+	options := Options{"footnotes": true, "skip-html": true, "smartypants": false}
+	html = string(options.Render(options.Parse(input)))
+	if !strings.Contains(html, `<div class="footnotes">`) {
+		t.Errorf("footnotes weren't rendered: %q", html)
+	}
+	if strings.Contains(html, "<sup>1</sup>") || strings.Contains(html, "&ndash;") {
+		t.Errorf("raw HTML or smart dashes were rendered: %q", html)
+	}
+}
@@ -0,0 +1,244 @@
+package page
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/aedipamoss/stationery/config"
+)
+
+// DatePrefixRegex matches the date prefix Publish adds when renaming a file.
+const DatePrefixRegex = `^\d{4}-\d{2}-\d{2}-`
+
+// Changefreqs are the values allowed for `changefreq`, from the sitemap protocol.
+var Changefreqs = []string{"always", "hourly", "daily", "weekly", "monthly", "yearly", "never"}
+
+// Matches the line number yaml puts in front of its errors.
+var yamlLineRegex = regexp.MustCompile(`line (\d+)`)
+
+// CheckFrontMatter strictly parses the front-matter of content.
+// Unknown keys and invalid values are reported with their line number in content.
+func CheckFrontMatter(content []byte) []error {
+	match := regexp.MustCompile(FrontMatterRegex).FindSubmatchIndex(content)
+	if match == nil {
+		return nil
+	}
+
+	// yaml counts lines from the opening "---", which is this far into the file
+	offset := strings.Count(string(content[:match[2]]), "\n")
+	fixLine := func(msg string) string {
+		return yamlLineRegex.ReplaceAllStringFunc(msg, func(line string) string {
+			n, _ := strconv.Atoi(strings.TrimPrefix(line, "line "))
+			return fmt.Sprintf("line %d", n+offset)
+		})
+	}
+
+	page := Page{}
+	var errs []error
+	err := yaml.UnmarshalStrict(content[match[2]:match[3]], &page.Data)
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, fmt.Errorf("%s", config.Humanize(fixLine(msg))))
+		}
+	} else if err != nil {
+		return []error{fmt.Errorf("%s", fixLine(err.Error()))}
+	}
+
+	stamps := []struct{ key, value string }{
+		{"timestamp", page.Data.Timestamp},
+		{"updated", page.Data.Updated},
+	}
+	for _, stamp := range stamps {
+		if stamp.value != "" {
+			_, err = time.Parse(time.RFC3339, stamp.value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s %q is not in RFC3339 format", stamp.key, stamp.value))
+			}
+		}
+	}
+
+	if unsafeSlug(page.Data.Slug) {
+		errs = append(errs, fmt.Errorf("slug %q can't contain \"/\", \"\\\" or \"..\"", page.Data.Slug))
+	}
+
+	if page.Data.Changefreq != "" && !contains(Changefreqs, page.Data.Changefreq) {
+		errs = append(errs, fmt.Errorf("changefreq %q is not one of %s", page.Data.Changefreq, strings.Join(Changefreqs, ", ")))
+	}
+
+	if priority := page.Data.Priority; priority != nil && (*priority < 0 || *priority > 1) {
+		errs = append(errs, fmt.Errorf("priority %v is not between 0.0 and 1.0", *priority))
+	}
+
+	errs = append(errs, page.Data.Markdown.Check()...)
+
+	if page.Data.SeriesOrder != 0 && page.Data.Series == "" {
+		errs = append(errs, fmt.Errorf("series_order %d is set without a series", page.Data.SeriesOrder))
+	}
+
+	return errs
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Split a line into its content and line ending, so the ending can be kept as is.
+func splitLineEnding(line string) (string, string) {
+	if strings.HasSuffix(line, "\r\n") {
+		return line[:len(line)-2], "\r\n"
+	}
+	if strings.HasSuffix(line, "\n") {
+		return line[:len(line)-1], "\n"
+	}
+	return line, ""
+}
+
+// Returns the line ending used by content, "\r\n" when its first line ends with it.
+func lineEnding(content []byte) string {
+	line := content
+	if i := bytes.IndexByte(content, '\n'); i >= 0 {
+		line = content[:i+1]
+	}
+	if bytes.HasSuffix(line, []byte("\r\n")) {
+		return "\r\n"
+	}
+
+	return "\n"
+}
+
+// Lines belonging to the value of the previous key, like a nested map or a list.
+func isContinuation(line string) bool {
+	return strings.HasPrefix(line, " ") ||
+		strings.HasPrefix(line, "\t") ||
+		strings.HasPrefix(line, "- ")
+}
+
+// SetFrontMatter sets a top-level key in the front-matter of content to value.
+//
+// Only the line(s) holding that key are rewritten, every other byte of the
+// front-matter and the content is left untouched so unrelated YAML keeps its formatting.
+// The key is appended to the front-matter when missing,
+// and front-matter is added when content has none, both with the line endings of content.
+func SetFrontMatter(content []byte, key string, value string) []byte {
+	eol := lineEnding(content)
+	r := regexp.MustCompile(FrontMatterRegex)
+	match := r.FindSubmatchIndex(content)
+	if match == nil {
+		header := fmt.Sprintf("---%s%s: %s%s---%s", eol, key, value, eol, eol)
+		return append([]byte(header), content...)
+	}
+
+	start, end := match[2], match[3]
+	lines := strings.SplitAfter(string(content[start:end]), "\n")
+
+	var buf strings.Builder
+	buf.WriteString(lines[0])
+	found := false
+	replacing := false
+
+	for _, line := range lines[1:] {
+		if replacing && isContinuation(line) {
+			continue
+		}
+		replacing = false
+
+		if !found && strings.HasPrefix(line, key+":") {
+			_, ending := splitLineEnding(line)
+			if ending == "" {
+				ending = eol
+			}
+			buf.WriteString(key + ": " + value + ending)
+			found = true
+			replacing = true
+			continue
+		}
+
+		buf.WriteString(line)
+	}
+
+	if !found {
+		if !strings.HasSuffix(buf.String(), "\n") {
+			buf.WriteString(eol)
+		}
+		buf.WriteString(key + ": " + value + eol)
+	}
+
+	out := make([]byte, 0, len(content)+buf.Len())
+	out = append(out, content[:start]...)
+	out = append(out, buf.String()...)
+	return append(out, content[end:]...)
+}
+
+// Publish turns the draft at path into a published page.
+//
+// It sets `draft: false` and stamps `timestamp` with now.
+// When rename is true the file is also moved to a date-prefixed name,
+// so "src/my-post.md" becomes "src/2018-08-13-my-post.md".
+// It returns the path of the published file.
+func Publish(path string, now time.Time, rename bool) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return path, err
+	}
+
+	content = SetFrontMatter(content, "draft", "false")
+	content = SetFrontMatter(content, "timestamp", now.Format(time.RFC3339))
+
+	dest := path
+	if rename {
+		name := regexp.MustCompile(DatePrefixRegex).ReplaceAllString(filepath.Base(path), "")
+		dest = filepath.Join(filepath.Dir(path), now.Format("2006-01-02")+"-"+name)
+		if _, err = os.Stat(dest); err == nil {
+			return path, fmt.Errorf("refusing to overwrite existing file: %s", dest)
+		}
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return path, err
+	}
+
+	// the published file replaces the draft at once, so a failure leaves the draft as it was
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return path, err
+	}
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Chmod(stat.Mode())
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return path, err
+	}
+
+	err = os.Rename(tmp.Name(), dest)
+	if err != nil {
+		return path, err
+	}
+
+	if dest != path {
+		err = os.Remove(path)
+	}
+
+	return dest, err
+}
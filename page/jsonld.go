@@ -0,0 +1,204 @@
+package page
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+)
+
+// Site holds the details of the whole site a page belongs to, from the config.
+type Site struct {
+	Title       string // name of the site
+	Description string // description of pages without one or any content
+	Author      string // name of the author of every page
+	Email       string
+	Image       string // image of the author, relative to the root of the site
+	Twitter     string // twitter user handle of the site
+	URL         string // absolute URL of the root of the site, empty without one like when previewing
+}
+
+// A JSON-LD object, see https://schema.org
+type jsonld map[string]interface{}
+
+// Returns the URL of an image relative to the root of the site.
+func (page Page) imageURL(image string) string {
+	if image == "" {
+		return ""
+	}
+
+	return page.base() + image
+}
+
+// Home is true for the index at the root of the site.
+func (page Page) isHome() bool {
+	p := page.Path()
+	return page.FileInfo == nil && (p == "" || p == "index.html")
+}
+
+// Post is true for a dated page written from a markdown file.
+func (page Page) isPost() bool {
+	return page.FileInfo != nil && !page.Standalone()
+}
+
+// Returns the author of the site as a schema.org Person, or nil without one.
+func (page Page) person() jsonld {
+	if page.Site.Author == "" {
+		return nil
+	}
+
+	person := jsonld{"@type": "Person", "name": page.Site.Author}
+	if page.Site.Email != "" {
+		person["email"] = page.Site.Email
+	}
+	if page.Site.Image != "" {
+		person["image"] = page.imageURL(page.Site.Image)
+	}
+
+	return person
+}
+
+func (page Page) website() jsonld {
+	site := jsonld{
+		"@context": "https://schema.org",
+		"@type":    "WebSite",
+		"name":     page.Site.Title,
+	}
+	if page.Site.URL != "" {
+		site["url"] = page.URL()
+	}
+	if page.Data.Description != "" {
+		site["description"] = page.Data.Description
+	}
+
+	return site
+}
+
+func (page Page) blogPosting() jsonld {
+	post := jsonld{
+		"@context":      "https://schema.org",
+		"@type":         "BlogPosting",
+		"headline":      page.Title(),
+		"datePublished": page.Date().Format(time.RFC3339),
+		"dateModified":  page.Modified().Format(time.RFC3339),
+	}
+	if canonical := page.Canonical(); canonical != "" {
+		post["url"] = canonical
+		post["mainEntityOfPage"] = canonical
+	}
+	if description := page.metaDescription(); description != "" {
+		post["description"] = description
+	}
+	if page.Data.Image != "" {
+		post["image"] = page.imageURL(page.Data.Image)
+	}
+	if len(page.Data.Tags) > 0 {
+		post["keywords"] = strings.Join(page.Data.Tags, ", ")
+	}
+	if person := page.person(); person != nil {
+		post["author"] = person
+	}
+
+	return post
+}
+
+// Returns the trail of pages from the home page to this post, through its section.
+// Like Canonical(), there are no URLs in it without the URL of the site.
+func (page Page) breadcrumbs() jsonld {
+	home := page
+	home.Data.Title = page.Site.Title
+	home.Dir = ""
+	home.FileInfo = nil
+	home.Destination = "index.html"
+	home.Data.Slug = ""
+	home.Permalinks = nil
+	trail := []Page{home}
+
+	if section := page.Section(); section != "" {
+		index := home
+		index.Data.Title = section
+		if page.SectionPage != nil {
+			index.Data.Title = page.SectionPage.Title()
+		}
+		index.Dir = section
+		trail = append(trail, index)
+	}
+	trail = append(trail, page)
+
+	var items []jsonld
+	for i, p := range trail {
+		item := jsonld{
+			"@type":    "ListItem",
+			"position": i + 1,
+			"name":     p.Title(),
+		}
+		if page.Site.URL != "" {
+			item["item"] = p.URL()
+		}
+		items = append(items, item)
+	}
+
+	return jsonld{
+		"@context":        "https://schema.org",
+		"@type":           "BreadcrumbList",
+		"itemListElement": items,
+	}
+}
+
+// Returns a value decoded from YAML in a form encoding/json can marshal,
+// YAML maps are decoded with keys of any type.
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = jsonValue(item)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// StructuredData builds the JSON-LD blocks for the header of a page.
+// The home page describes the WebSite and its author, posts are a BlogPosting with their breadcrumbs.
+// The `jsonld` front-matter is merged over the main block, replacing its keys.
+func (page Page) StructuredData() template.HTML {
+	var blocks []jsonld
+	switch {
+	case page.isHome():
+		blocks = append(blocks, page.website())
+		if person := page.person(); person != nil {
+			person["@context"] = "https://schema.org"
+			blocks = append(blocks, person)
+		}
+	case page.isPost():
+		blocks = append(blocks, page.blogPosting(), page.breadcrumbs())
+	default:
+		return ""
+	}
+
+	for key, value := range page.Data.JSONLD {
+		blocks[0][key] = jsonValue(value)
+	}
+
+	var str string
+	for _, block := range blocks {
+		// encoding/json escapes <, > and &, so the block can't close the script element
+		out, err := json.Marshal(block)
+		if err != nil {
+			panic(err)
+		}
+		str += `<script type="application/ld+json">` + string(out) + "</script>\n\t"
+	}
+
+	// nolint: gosec
+	return template.HTML(str)
+}
@@ -0,0 +1,83 @@
+package page
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// The markup of the helpers made available to page templates.
+// They are html/template so every attribute and text from the front-matter is escaped,
+// lines are separated by "\n\t" to line up in the head of a layout.
+var (
+	tagsMarkup = template.Must(template.New("tags").Parse(
+		`{{ if . }}<br>{{ range . }}<span class="tag">#<a href="{{ .Href }}">{{ .Text }}</a></span>{{ end }}{{ end }}`))
+
+	headersMarkup = template.Must(template.New("headers").Parse(
+		"<title>{{ .Title }}</title>\n\t" +
+			`<meta charset="utf-8">` + "\n\t" +
+			`{{ with .Canonical }}<link rel="canonical" href="{{ . }}">` + "\n\t{{ end }}" +
+			"{{ .MetaTags }}{{ .AssetTags }}{{ .StructuredData }}"))
+
+	metaMarkup = template.Must(template.New("meta").Parse(
+		`{{ range . }}<meta {{ if .Name }}name="{{ .Name }}"{{ else }}property="{{ .Property }}"{{ end }} content="{{ .Content }}" />` + "\n\t{{ end }}"))
+
+	assetsMarkup = template.Must(template.New("assets").Parse(
+		`{{ range . }}<link type="text/css" rel="stylesheet" href="{{ . }}">` + "\n\t{{ end }}"))
+
+	menuMarkup = template.Must(template.New("menu").Parse(
+		`{{ if . }}<ul class="menu">` + "\n\t" +
+			`{{ range . }}<li><a href="{{ .Href }}">{{ .Text }}</a></li>` + "\n\t{{ end }}" +
+			"</ul>\n\t{{ end }}"))
+
+	indexMarkup = template.Must(template.New("index").Parse(
+		"{{ if . }}<ul>\n\t" +
+			"{{ range . }}<li>{{ . }}</li>\n\t{{ end }}" +
+			"</ul>\n\t{{ end }}"))
+
+	seriesMarkup = template.Must(template.New("series").Parse(
+		`{{ if .Parts }}<nav class="series">` + "\n\t" +
+			"<p>Part {{ .Part }} of {{ .Total }} in {{ .Name }}</p>\n\t<ol>\n\t" +
+			`{{ range .Parts }}<li>{{ if .Current }}<strong>{{ .Text }}</strong>{{ else }}<a href="{{ .Href }}">{{ .Text }}</a>{{ end }}</li>` + "\n\t{{ end }}" +
+			"</ol>\n\t</nav>\n\t{{ end }}"))
+
+	tocMarkup = template.Must(template.New("toc").Parse(
+		`{{ define "headings" }}<ul>{{ range . }}<li><a href="{{ .Href }}">{{ .Title }}</a>` +
+			`{{ if .Children }}{{ template "headings" .Children }}{{ end }}</li>{{ end }}</ul>{{ end }}` +
+			`{{ if . }}<nav class="toc">{{ template "headings" . }}</nav>` + "\n{{ end }}"))
+
+	linkMarkup = template.Must(template.New("link").Parse(
+		`<a href="{{ .Href }}">{{ .Page.Title }}</a><br>` +
+			`<span class="page_date">{{ .Page.DateString }}</span>{{ .Page.Tags }}`))
+)
+
+// A link to a page or a tag.
+type link struct {
+	Href string
+	Text string
+}
+
+// A part of a series, Current for the page showing it.
+type seriesPart struct {
+	Href    string
+	Text    string
+	Current bool
+}
+
+// A meta tag, named by either Name or Property.
+type meta struct {
+	Name     string
+	Property string
+	Content  string
+}
+
+// Execute the markup of a helper with data, the result is safe to put in a page.
+func markup(tmpl *template.Template, data interface{}) template.HTML {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, data)
+	if err != nil {
+		panic(err)
+	}
+
+	// nolint: gosec
+	return template.HTML(buf.String())
+}
@@ -5,39 +5,79 @@ import (
 	"bytes"
 	"fmt"
 	"html/template"
+	"image"
+	// decoders for imageSize
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io/ioutil"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/aedipamoss/stationery/assets"
 	"github.com/aedipamoss/stationery/fileutils"
-	blackfriday "gopkg.in/russross/blackfriday.v2"
+	"github.com/aedipamoss/stationery/markdown"
 	yaml "gopkg.in/yaml.v2"
 )
 
 // Page contains everything needed to build a page and write it.
 type Page struct {
 	Assets   *assets.List  // assets available to this page
+	BaseURL  string        // absolute root of the site used by URL(), defaults to Root
 	Children []*Page       // children pages used for index templates
 	Content  template.HTML // parsed content into HTML
 	Data     struct {      // extracted meta-data from the file
+		Aliases     []string // old paths redirecting to this page
+		Canonical   string   // URL of the original page, when this one is a copy
+		Changefreq  string   // how often the page changes, for the sitemap
 		Description string
+		Draft       bool // drafts are skipped when generating the site
 		Image       string
+		JSONLD      map[string]interface{} `yaml:"jsonld"` // overrides keys of the structured data, see StructuredData()
+		Markdown    markdown.Options       // overrides the markdown options of the config for this page
+		Noindex     bool                   // keeps search engines away, and the page out of the sitemap
+		Priority    *float64               // priority in the sitemap from 0.0 to 1.0, left out when not set
+		Series      string                 // name of the series of posts this one is part of
+		SeriesOrder int                    `yaml:"series_order"` // position in its series, see Series()
+		Slug        string                 // overrides the slug taken from the file name
 		Title       string
 		Timestamp   string
 		Tags        []string
+		TOC         bool   // puts the table of contents at the beginning of the content
 		Twitter     string // twitter user handle who created this page
-	}
-	Destination string      // path to write this page out to
-	FileInfo    os.FileInfo // original source file info
-	Raw         string      // raw markdown after subbing data
-	Root        string      // parent of this page, usually config.SiteURL
-	Source      string      // path to the original source file
-	Template    string      // template used for this page
+		Type        string // "page" for standalone pages, see Standalone()
+		Updated     string // when the page was last changed, in the same format as Timestamp
+		Weight      int    // order of standalone pages in the menu
+	}
+	Destination     string            // path to write this page out to
+	Dir             string            // directory of the source relative to the configured source
+	FileInfo        os.FileInfo       // original source file info
+	ImageHeight     int               // size of Data.Image when it's generated, see ImageWidth
+	ImageWidth      int               // size of Data.Image when it's generated, instead of reading it from assets.Dir
+	Layout          string            // template text used instead of reading Template, for built-in layouts
+	Markdown        markdown.Options  // options to render the content, see config.Config.Markdown
+	MenuPages       []*Page           // standalone pages linked from Menu()
+	Permalinks      map[string]string // patterns for Path(), see config.Config.Permalinks
+	Posts           []*Page           // every post of the site newest first, see Prev() and Next()
+	PrettyURLs      bool              // write pages as directory indexes, see config.Config.PrettyURLs
+	Raw             string            // raw markdown after subbing data
+	RelatedPages    []*Page           // most related posts first, see Related()
+	RelativeURLs    bool              // Root is relative to this page, see config.Config.RelativeURLs
+	Root            string            // parent of this page, usually config.SiteURL
+	SectionPage     *Page             // index of the section of this page, named in its breadcrumbs
+	SeriesPages     []*Page           // parts of the series of this page in order, see Series()
+	Site            Site              // details of the whole site, from the config
+	Source          string            // path to the original source file
+	SummaryContent  template.HTML     // content up to the MoreMarker, empty without one, see Summary()
+	TableOfContents []*Heading        // headings of the content nested by level, see TOC()
+	Template        string            // template used for this page
 }
 
 // Timestamp is a member function made available in the page template.
@@ -49,169 +89,310 @@ func (page Page) Timestamp(timestamp string) string {
 
 // Slug is used to reference the destination for a page without the extension.
 // It's used both in generate.IndexTemplate and (*page.Page).setDestination()
+// A slug from the front-matter which could lead out of its directory is slugified, see unsafeSlug().
 func (page Page) Slug() string {
-	if page.FileInfo != nil {
-		return fileutils.Basename(page.FileInfo)
+	slug := page.Data.Slug
+	if unsafeSlug(slug) {
+		slug = Slugify(slug)
+	}
+	if slug != "" {
+		return slug
 	}
 
-	stat, err := os.Stat(page.Destination)
-	if err != nil {
-		panic(err)
+	if page.FileInfo != nil {
+		return fileutils.Basename(page.FileInfo)
 	}
 
-	return fileutils.Basename(stat)
+	name := filepath.Base(page.Destination)
+	return strings.TrimSuffix(name, filepath.Ext(name))
 }
 
-// Title is used when printing the index page as the anchor text currently in generate.IndexTemplate.
-func (page Page) Title() string {
-	if page.Data.Title != "" {
-		return page.Data.Title
+// Section is the top-level directory of the source this page is in.
+// It's empty for pages at the top-level of the source.
+func (page Page) Section() string {
+	if page.Dir == "" {
+		return ""
 	}
 
-	return page.Slug()
+	return strings.Split(filepath.ToSlash(page.Dir), "/")[0]
 }
 
-// FIXME(ae): These general string helpers
+// Returns true when a slug from the front-matter holds a path rather than a single name.
+func unsafeSlug(slug string) bool {
+	return strings.ContainsAny(slug, `/\`) || strings.Contains(slug, "..")
+}
 
-// Write a bunch of strings to a buffer then return a string
-func toString(strs ...string) string {
+// Slugify turns a title into something usable as a file name or URL.
+// Letters and digits are lower-cased and kept, everything else becomes a single dash.
+func Slugify(title string) string {
 	var buf bytes.Buffer
-	var err error
-
-	for _, str := range strs {
-		_, err = buf.Write([]byte(str))
-		if err != nil {
-			panic(err)
+	dash := false
+
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && buf.Len() > 0 {
+				buf.WriteRune('-')
+			}
+			buf.WriteRune(r)
+			dash = false
+			continue
 		}
+		dash = true
 	}
 
 	return buf.String()
 }
 
-func newline() string {
-	return "\n\t"
+// Title is used when printing the index page as the anchor text currently in generate.IndexTemplate.
+func (page Page) Title() string {
+	if page.Data.Title != "" {
+		return page.Data.Title
+	}
+
+	return page.Slug()
 }
 
 // Tags will build a list of tags and their links in an HTML safe way.
 func (page Page) Tags() template.HTML {
-	var str string
-	if len(page.Data.Tags) > 0 {
-		str += "<br>"
-		for _, tag := range page.Data.Tags {
-			str += toString(
-				`<span class="tag">#`,
-				fmt.Sprintf(`<a href="%stag/%s.html">`, page.Root, tag),
-				tag,
-				`</a>`,
-				`</span>`,
-			)
-		}
+	var links []link
+	for _, tag := range page.Data.Tags {
+		links = append(links, link{Href: page.href(page.TagPath(tag)), Text: tag})
 	}
-	// nolint: gosec
-	return template.HTML(str)
+
+	return markup(tagsMarkup, links)
 }
 
 // Headers combines title, meta-tags, and assets into a single function.
 func (page Page) Headers() template.HTML {
-	str := toString(
-		`<title>`,
-		page.Title(),
-		`</title>`,
-		newline(),
-		`<meta charset="utf-8">`,
-		newline(),
-		page.MetaTags(),
-		page.AssetTags(),
-	)
-
-	// nolint: gosec
-	return template.HTML(str)
+	return markup(headersMarkup, page)
 }
 
 // MetaTags builds a list of meta tags for the header of a page.
-func (page Page) MetaTags() string {
-	var str string
-	if page.Data.Description != "" {
-		str += toString(
-			fmt.Sprintf(`<meta name="description" content="%s" />`, page.Data.Description),
-			newline(),
-			fmt.Sprintf(`<meta property="og:description" content="%s" />`, page.Data.Description),
-			newline(),
+func (page Page) MetaTags() template.HTML {
+	var tags []meta
+	if description := page.metaDescription(); description != "" {
+		tags = append(tags,
+			meta{Name: "description", Content: description},
+			meta{Property: "og:description", Content: description},
 		)
 	}
 
+	if page.isPost() {
+		tags = append(tags, meta{Property: "og:type", Content: "article"})
+	} else {
+		tags = append(tags, meta{Property: "og:type", Content: "website"})
+	}
+
+	if page.Site.Title != "" {
+		tags = append(tags, meta{Property: "og:site_name", Content: page.Site.Title})
+	}
+
+	card := "summary"
+	if page.Data.Image != "" {
+		card = "summary_large_image"
+	}
+	tags = append(tags, meta{Name: "twitter:card", Content: card})
+
+	if page.Site.Twitter != "" {
+		tags = append(tags, meta{Name: "twitter:site", Content: "@" + page.Site.Twitter})
+	}
+
 	if page.Data.Twitter != "" {
-		str += toString(`<meta name="twitter:card" content="summary" />`,
-			newline(),
-			fmt.Sprintf(`<meta name="twitter:site" content="@%s" />`, page.Data.Twitter),
-			newline(),
-			fmt.Sprintf(`<meta name="twitter:creator" content="@%s" />`, page.Data.Twitter),
-			newline(),
-		)
+		tags = append(tags, meta{Name: "twitter:creator", Content: "@" + page.Data.Twitter})
+	}
+
+	if page.Data.Noindex {
+		tags = append(tags, meta{Name: "robots", Content: "noindex"})
 	}
 
-	str += toString(
-		fmt.Sprintf(`<meta property="og:url" content="%s" />`, page.URL()),
-		newline(),
-		fmt.Sprintf(`<meta property="og:title" content="%s" />`, page.Title()),
-		newline(),
-	)
+	if canonical := page.Canonical(); canonical != "" {
+		tags = append(tags, meta{Property: "og:url", Content: canonical})
+	}
+	tags = append(tags, meta{Property: "og:title", Content: page.Title()})
 
 	if page.Data.Image != "" {
-		str += fmt.Sprintf(`<meta property="og:image" content="%s%s" />`, page.Root, page.Data.Image)
-		str += newline()
+		tags = append(tags, meta{Property: "og:image", Content: page.imageURL(page.Data.Image)})
+		width, height, ok := page.ImageWidth, page.ImageHeight, page.ImageWidth > 0
+		if !ok {
+			width, height, ok = imageSize(page.Data.Image)
+		}
+		if ok {
+			tags = append(tags,
+				meta{Property: "og:image:width", Content: strconv.Itoa(width)},
+				meta{Property: "og:image:height", Content: strconv.Itoa(height)},
+			)
+		}
 	}
 
-	return str
+	if page.isPost() {
+		tags = append(tags,
+			meta{Property: "article:published_time", Content: page.Date().Format(time.RFC3339)},
+			meta{Property: "article:modified_time", Content: page.Modified().Format(time.RFC3339)},
+		)
+		for _, tag := range page.Data.Tags {
+			tags = append(tags, meta{Property: "article:tag", Content: tag})
+		}
+	}
+
+	return markup(metaMarkup, tags)
+}
+
+// Returns the size of an image from the root of the site, which is copied from assets.Dir.
+// It's only known for GIF, JPEG and PNG images found there.
+func imageSize(name string) (width int, height int, ok bool) {
+	f, err := os.Open(filepath.Join(assets.Dir, filepath.FromSlash(name)))
+	if err != nil {
+		return 0, 0, false
+	}
+	defer f.Close() // nolint: errcheck
+
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return config.Width, config.Height, true
 }
 
 // AssetTags returns meta tags for all assets from the project config
-func (page Page) AssetTags() string {
-	var str string
-	if page.Assets != nil && len(page.Assets.CSS) > 0 {
+func (page Page) AssetTags() template.HTML {
+	var stylesheets []string
+	if page.Assets != nil {
 		for _, stylesheet := range page.Assets.CSS {
-			str += fmt.Sprintf(`<link type="text/css" rel="stylesheet" href="%scss/%s">`, page.Root, stylesheet)
-			str += newline()
+			stylesheets = append(stylesheets, page.Root+"css/"+stylesheet)
 		}
 	}
 
-	return str
+	return markup(assetsMarkup, stylesheets)
+}
+
+// PagesDir is the directory of the source where every file is a standalone page.
+const PagesDir = "pages"
+
+// Standalone pages, like "About", are rendered on their own but left out of indexes, tags and feeds.
+// A page is standalone when it's in PagesDir or has `type: page` in its front-matter.
+func (page Page) Standalone() bool {
+	return page.Data.Type == "page" || page.Section() == PagesDir
+}
+
+// Menu builds a list of links to the standalone pages
+func (page Page) Menu() template.HTML {
+	var links []link
+	for _, p := range page.MenuPages {
+		links = append(links, link{Href: page.href(p.Path()), Text: p.Title()})
+	}
+
+	return markup(menuMarkup, links)
 }
 
 // Index builds a list of children and links to their pages
 func (page Page) Index() template.HTML {
-	var str string
-	if len(page.Children) > 0 {
-		str += `<ul>`
-		str += newline()
-		for _, child := range page.Children {
-			str += `<li>`
-			str += child.Link()
-			str += `</li>`
-			str += newline()
-		}
-		str += `</ul>`
-		str += newline()
+	var items []template.HTML
+	for _, child := range page.Children {
+		items = append(items, page.linked(child).Link())
 	}
 
-	// nolint: gosec
-	return template.HTML(str)
+	return markup(indexMarkup, items)
 }
 
-// Link is used when printing a page's link inside page.Index()
-func (page Page) Link() string {
-	str := toString(
-		fmt.Sprintf(`<a href="%s%s.html">`, page.Root, page.Slug()),
-		page.Title(),
-		"</a>",
-		"<br>",
-		`<span class="page_date">`,
-		page.DateString(),
-		"</span>")
+// Path is where this page lives relative to the root of the site, like "notes/go/generics.html".
+// It always uses forward slashes so it can be used in URLs,
+// and ends with a slash when the page is written as the index of a directory.
+func (page Page) Path() string {
+	p := path.Join(filepath.ToSlash(page.Dir), page.Slug()+".html")
+	if pattern := page.permalink(); pattern != "" {
+		p = page.expandPermalink(pattern)
+	}
 
-	str += string(page.Tags())
+	return page.pretty(p)
+}
 
-	return str
+// TagPath is where the index of a tag lives relative to the root of the site, like "tag/go.html".
+func (page Page) TagPath(tag string) string {
+	return page.pretty(path.Join("tag", tag+".html"))
+}
+
+// Turn the path to an HTML file into the path of its directory when using pretty URLs,
+// so "notes/go.html" becomes "notes/go/" and "notes/index.html" becomes "notes/".
+func (page Page) pretty(p string) string {
+	if !page.PrettyURLs || path.Ext(p) != ".html" {
+		return p
+	}
+
+	if path.Base(p) == "index.html" {
+		return strings.TrimSuffix(p, "index.html")
+	}
+
+	return strings.TrimSuffix(p, ".html") + "/"
+}
+
+// FilePath returns the file written for a path from Path() or TagPath(),
+// which is the index.html of a directory for paths ending with a slash.
+func FilePath(p string) string {
+	if p == "" || strings.HasSuffix(p, "/") {
+		p += "index.html"
+	}
+
+	return filepath.FromSlash(p)
+}
+
+// Returns the root of the site to use in absolute URLs.
+func (page Page) base() string {
+	if page.BaseURL != "" {
+		return page.BaseURL
+	}
+
+	return page.Root
+}
+
+// Returns the link to a path from Path() or TagPath() to use in this page.
+// Relative links to a directory name its index.html, so they work without a web server.
+func (page Page) href(p string) string {
+	if page.RelativeURLs && (p == "" || strings.HasSuffix(p, "/")) {
+		p += "index.html"
+	}
+
+	return page.Root + p
+}
+
+// Home is a member function made available in the page template.
+// It links to the index of the site from this page.
+func (page Page) Home() string {
+	return page.href("")
+}
+
+// Href is the link to this page from its Root.
+func (page Page) Href() string {
+	return page.href(page.Path())
+}
+
+// Related is a member function made available in the page template.
+// It lists the posts most related to this one, so you can write
+// `{{ range .Related }}<a href="{{ .Href }}">{{ .Title }}</a>{{ end }}`.
+func (page Page) Related() []*Page {
+	var related []*Page
+	for _, p := range page.RelatedPages {
+		related = append(related, page.linked(p))
+	}
+
+	return related
+}
+
+// Returns a copy of p with links made from this page, which matters when they're relative.
+func (page Page) linked(p *Page) *Page {
+	link := *p
+	link.Root = page.Root
+	link.RelativeURLs = page.RelativeURLs
+
+	return &link
+}
+
+// Link is used when printing a page's link inside page.Index()
+func (page Page) Link() template.HTML {
+	return markup(linkMarkup, struct {
+		Href string
+		Page Page
+	}{page.Href(), page})
 }
 
 // URL is used when generating the rss feed for the site.
@@ -221,24 +402,41 @@ func (page Page) URL() string {
 		panic(err)
 	}
 
-	url, err = url.Parse(page.Root)
+	url, err = url.Parse(page.base())
 	if err != nil {
 		panic(err)
 	}
 
-	url.Path = path.Join(url.Path, page.Slug()+".html")
+	p := page.Path()
+	url.Path = path.Join(url.Path, p)
+	if (p == "" || strings.HasSuffix(p, "/")) && !strings.HasSuffix(url.Path, "/") {
+		url.Path += "/"
+	}
 	return url.String()
 }
 
 // Description is used when generating the rss feed for the site.
+// It falls back to the summary of the page, then its title.
 func (page Page) Description() string {
 	if page.Data.Description != "" {
 		return page.Data.Description
 	}
 
+	if summary := page.SummaryText(); summary != "" {
+		return summary
+	}
+
 	return page.Title()
 }
 
+// Matches HTML tags, to turn the content into plain text.
+var tagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// Text is the content of the page as plain text, without any markup or extra white-space.
+func (page Page) Text() string {
+	return plain(string(page.Content))
+}
+
 // DateString returns a string formatted date of the (*page).Date()
 func (page Page) DateString() string {
 	return page.Date().Format("Jan _2, 2006")
@@ -262,13 +460,47 @@ func (page Page) Date() time.Time {
 	return time.Now()
 }
 
+// Modified is when the page was last changed, from its `updated` front-matter or its Date().
+func (page Page) Modified() time.Time {
+	if page.Data.Updated != "" {
+		t, err := time.Parse(time.RFC3339, page.Data.Updated)
+		if err != nil {
+			panic(err)
+		}
+
+		return t
+	}
+
+	return page.Date()
+}
+
+// Canonical is the preferred URL of this page, its own URL() unless the front-matter names another one.
+// A canonical path without a scheme is relative to the root of the site.
+// It's empty when the site has no URL, like when previewing, unless the front-matter has an absolute one.
+func (page Page) Canonical() string {
+	if u, err := url.Parse(page.Data.Canonical); err == nil && u.IsAbs() {
+		return page.Data.Canonical
+	}
+
+	if page.Site.URL == "" {
+		return ""
+	}
+
+	if page.Data.Canonical == "" {
+		return page.URL()
+	}
+
+	return page.Site.URL + strings.TrimPrefix(page.Data.Canonical, "/")
+}
+
 // FrontMatterRegex is a regular expression inspired by Jekyll.
 // They have a constant YAML_FRONT_MATTER_REGEX, which is here:
 //   https://github.com/jekyll/jekyll/blob/a944dd9/lib/jekyll/document.rb#L13
 //
 // We use this to pull out meta-data from the page content before parsing.
 // The first use-case for this was a page title.
-const FrontMatterRegex = `(?s)(---\s*\n.*?\n?)(---\s*\n?)`
+// Only a block at the start of the file counts, so later `---` rules stay in the content.
+const FrontMatterRegex = `(?s)\A\s*(---\s*\n.*?\n?)(---\s*\n?)`
 
 // Parses the front-matter data into the page and returns the content stripped of meta-data.
 // This function is called directly by parseRaw().
@@ -301,6 +533,15 @@ func (page *Page) parseRaw() error {
 		return err
 	}
 
+	for _, stamp := range []string{page.Data.Timestamp, page.Data.Updated} {
+		if stamp != "" {
+			_, err = time.Parse(time.RFC3339, stamp)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	page.Raw = raw
 
 	return err
@@ -313,7 +554,8 @@ func (page *Page) parseRaw() error {
 func (page *Page) executeContent() ([]byte, error) {
 	buf := new(bytes.Buffer)
 	tpl := template.New("content")
-	tpl, err := tpl.Parse(page.Raw)
+	// html/template drops comments, so the MoreMarker is kept out of its way
+	tpl, err := tpl.Parse(strings.Replace(page.Raw, MoreMarker, morePlaceholder, -1))
 	if err != nil {
 		return buf.Bytes(), err
 	}
@@ -323,7 +565,7 @@ func (page *Page) executeContent() ([]byte, error) {
 		return buf.Bytes(), err
 	}
 
-	return buf.Bytes(), err
+	return bytes.Replace(buf.Bytes(), []byte(morePlaceholder), []byte(MoreMarker), -1), err
 }
 
 // Set the page content after parsing the markdown.
@@ -333,9 +575,22 @@ func (page *Page) parseContent() error {
 	if err != nil {
 		return err
 	}
-	parsed := blackfriday.Run(buf)
+
+	// headings get their IDs between parsing and rendering
+	options := page.Markdown.Merge(page.Data.Markdown)
+	ast := options.Parse(buf)
+	page.TableOfContents = headings(ast)
+	parsed := options.Render(ast)
+
+	// the table of contents is only inserted by Generate()
 	// nolint: gosec
-	page.Content = template.HTML(string(parsed[:]))
+	page.Content = template.HTML(markTOC(string(parsed), true))
+
+	page.SummaryContent = ""
+	if cutAtMore(ast) {
+		// nolint: gosec
+		page.SummaryContent = template.HTML(strings.TrimSpace(markTOC(string(options.Render(ast)), false)))
+	}
 
 	return nil
 }
@@ -352,12 +607,12 @@ func (page *Page) setSource(src string) error {
 		return nil
 	}
 
-	page.Source = filepath.Join(src, name)
+	page.Source = filepath.Join(src, page.Dir, name)
 	return nil
 }
 
 func (page *Page) setDestination(dest string) error {
-	page.Destination = filepath.Join(dest, page.Slug()+".html")
+	page.Destination = filepath.Join(dest, FilePath(page.Path()))
 
 	return nil
 }
@@ -369,24 +624,30 @@ func (page *Page) Load(src string, dest string) error {
 		return err
 	}
 
-	err = page.setDestination(dest)
+	err = page.parseRaw()
 	if err != nil {
 		return err
 	}
 
-	err = page.parseRaw()
+	err = page.parseContent()
 	if err != nil {
 		return err
 	}
 
-	err = page.parseContent()
+	// the destination depends on front-matter, like the slug or date
+	err = page.setDestination(dest)
 
 	return err
 }
 
-// Create a buffered writer at the page destination.
+// Create a buffered writer at the page destination, along with any missing directories.
 // This function is called directly in Generate().
 func (page *Page) createDestination() (*bufio.Writer, error) {
+	err := os.MkdirAll(filepath.Dir(page.Destination), 0700)
+	if err != nil {
+		return nil, err
+	}
+
 	f, err := os.Create(page.Destination)
 	if err != nil {
 		return nil, err
@@ -397,6 +658,10 @@ func (page *Page) createDestination() (*bufio.Writer, error) {
 // Parse the page template to be ready for execution.
 // This function is called directly in Generate().
 func (page *Page) parseTemplate() (*template.Template, error) {
+	if page.Layout != "" {
+		return template.New("page").Parse(page.Layout)
+	}
+
 	tmpl, err := ioutil.ReadFile(page.Template)
 	if err != nil {
 		return nil, err
@@ -423,6 +688,9 @@ func (page Page) Generate() error {
 		return err
 	}
 
+	// nolint: gosec
+	page.Content = template.HTML(page.insertTOC(string(page.Content)))
+
 	err = tmpl.Execute(wrtr, page)
 	if err != nil {
 		return err
@@ -1,6 +1,15 @@
 package page
 
-import "testing"
+import (
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestTimestamp(t *testing.T) {
 	page := Page{}
@@ -10,3 +19,441 @@ func TestTimestamp(t *testing.T) {
 		t.Errorf("expected %v, got %v", expected, page.Timestamp(stamp))
 	}
 }
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"My Title":              "my-title",
+		"  Hello,   World!  ":   "hello-world",
+		"Go 1.10 release notes": "go-1-10-release-notes",
+		"Ædipa's café":          "ædipa-s-café",
+		"!!!":                   "",
+	}
+
+	for title, expected := range tests {
+		if slug := Slugify(title); slug != expected {
+			t.Errorf("Slugify(%q) = %q, expected %q", title, slug, expected)
+		}
+	}
+}
+
+func TestSetFrontMatter(t *testing.T) {
+	content := `---
+title:   "keep: my   formatting"
+draft: true
+tags:
+  - foo   # a comment
+timestamp:
+  2018-03-24T12:43:03Z
+---
+
+# body
+
+draft: true
+`
+
+	expected := `---
+title:   "keep: my   formatting"
+draft: false
+tags:
+  - foo   # a comment
+timestamp: 2018-08-13T23:20:49+09:00
+---
+
+# body
+
+draft: true
+`
+
+	out := SetFrontMatter([]byte(content), "draft", "false")
+	out = SetFrontMatter(out, "timestamp", "2018-08-13T23:20:49+09:00")
+	if string(out) != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+
+	body := "Intro para\n\n---\n\nmiddle\n\n---\n\nend"
+	out = SetFrontMatter([]byte(body), "draft", "false")
+	if string(out) != "---\ndraft: false\n---\n"+body {
+		t.Errorf("horizontal rules of the body were taken for front-matter, got %q", out)
+	}
+
+	out = SetFrontMatter([]byte("---\r\ntitle: crlf\r\n---\r\nbody"), "draft", "false")
+	if !strings.Contains(string(out), "title: crlf\r\ndraft: false\r\n---\r\nbody") {
+		t.Errorf("missing key was not appended, got %q", out)
+	}
+
+	out = SetFrontMatter([]byte("---\r\ndraft: true\r\n---\r\nbody"), "draft", "false")
+	if string(out) != "---\r\ndraft: false\r\n---\r\nbody" {
+		t.Errorf("key was not replaced keeping CRLF, got %q", out)
+	}
+
+	out = SetFrontMatter([]byte("# no front matter\r\n"), "draft", "false")
+	if string(out) != "---\r\ndraft: false\r\n---\r\n# no front matter\r\n" {
+		t.Errorf("front matter was not added with CRLF, got %q", out)
+	}
+
+	out = SetFrontMatter([]byte("# no front matter\n"), "draft", "false")
+	if string(out) != "---\ndraft: false\n---\n# no front matter\n" {
+		t.Errorf("front matter was not added, got %q", out)
+	}
+}
+
+func TestPublishFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stationery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "wip.md")
+	err = ioutil.WriteFile(path, []byte("---\r\ndraft: true\r\n---\r\nbody\r\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2018, 8, 13, 12, 0, 0, 0, time.UTC)
+	dest, err := Publish(path, now, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "---\r\ndraft: false\r\ntimestamp: 2018-08-13T12:00:00Z\r\n---\r\nbody\r\n"
+	if string(content) != expected {
+		t.Errorf("published %q, expected %q", content, expected)
+	}
+
+	stat, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.Mode().Perm() != 0600 {
+		t.Errorf("published file mode is %v, expected the one of the draft", stat.Mode())
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "2018-08-13-wip.md" {
+		t.Errorf("expected only the published file to be left, got %v", files)
+	}
+
+	// publishing over an existing file fails without touching the draft
+	draft := "---\ndraft: true\n---\nagain\n"
+	err = ioutil.WriteFile(path, []byte(draft), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Publish(path, now, true); err == nil {
+		t.Errorf("published over an existing file")
+	}
+	content, err = ioutil.ReadFile(path)
+	if err != nil || string(content) != draft {
+		t.Errorf("draft was changed by a failed publish: %q", content)
+	}
+}
+
+func TestPath(t *testing.T) {
+	page := Page{Dir: "notes"}
+	page.Data.Slug = "zomg"
+	page.Data.Title = "Zomg, a Title"
+	page.Data.Timestamp = time.Date(2018, 3, 4, 12, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	tests := map[string]string{
+		"":                          "notes/zomg.html",
+		":year/:month/:slug/":       "2018/03/zomg/",
+		":section/:slug.html":       "notes/zomg.html",
+		"/:year/:month/:day/:title": "2018/03/04/zomg-a-title.html",
+		"posts/:slug.htm":           "posts/zomg.htm",
+	}
+
+	for pattern, expected := range tests {
+		page.Permalinks = map[string]string{"default": pattern}
+		if path := page.Path(); path != expected {
+			t.Errorf("Path() with %q = %q, expected %q", pattern, path, expected)
+		}
+	}
+
+	page.Permalinks = map[string]string{"default": ":year/:slug/", "notes": ":section/:slug/"}
+	if path := page.Path(); path != "notes/zomg/" {
+		t.Errorf("section pattern wasn't preferred over the default, got %q", path)
+	}
+
+	page.Permalinks = nil
+	for _, slug := range []string{"../../zomg", `..\..\zomg`, "/zomg"} {
+		page.Data.Slug = slug
+		if path := page.Path(); path != "notes/zomg.html" {
+			t.Errorf("slug %q led to %q, expected it to stay in its directory", slug, path)
+		}
+	}
+	page.Data.Slug = "zomg"
+
+	page.Data.Type = "page"
+	page.Permalinks = map[string]string{"default": ":year/:slug/"}
+	if path := page.Path(); path != "notes/zomg.html" {
+		t.Errorf("standalone page used the default pattern, got %q", path)
+	}
+}
+
+func TestCheckFrontMatterSitemap(t *testing.T) {
+	tests := []struct {
+		frontMatter string
+		expected    string
+	}{
+		{"changefreq: weekly\npriority: 0.5\n", ""},
+		{"changefreq: sometimes\n", `changefreq "sometimes" is not one of`},
+		{"priority: 0.0\n", ""},
+		{"priority: 1.5\n", "priority 1.5 is not between 0.0 and 1.0"},
+		{"series: go\nseries_order: 2\n", ""},
+		{"series_order: 2\n", "series_order 2 is set without a series"},
+		{"slug: ../../zomg\n", `slug "../../zomg" can't contain`},
+	}
+
+	for _, test := range tests {
+		errs := CheckFrontMatter([]byte("---\n" + test.frontMatter + "---\n# zomg\n"))
+		if test.expected == "" {
+			if len(errs) > 0 {
+				t.Errorf("expected no problems for %q, got %v", test.frontMatter, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), test.expected) {
+			t.Errorf("expected %q for %q, got %v", test.expected, test.frontMatter, errs)
+		}
+	}
+}
+
+func TestNeighbours(t *testing.T) {
+	post := func(dir string, slug string, tags ...string) *Page {
+		p := &Page{Dir: dir, Source: slug + ".md"}
+		p.Data.Slug = slug
+		p.Data.Tags = tags
+		return p
+	}
+
+	// newest first, like the posts of a site
+	posts := []*Page{
+		post("notes", "d", "go"),
+		post("", "c"),
+		post("notes", "b"),
+		post("", "a", "go"),
+	}
+	for _, p := range posts {
+		p.Posts = posts
+	}
+
+	title := func(p *Page) string {
+		if p == nil {
+			return ""
+		}
+		return p.Data.Slug
+	}
+
+	tests := []struct {
+		name     string
+		got      *Page
+		expected string
+	}{
+		{"Prev() of c", posts[1].Prev(), "b"},
+		{"Next() of c", posts[1].Next(), "d"},
+		{"Prev() of a", posts[3].Prev(), ""},
+		{"Next() of d", posts[0].Next(), ""},
+		{"PrevInSection() of d", posts[0].PrevInSection(), "b"},
+		{"NextInSection() of a", posts[3].NextInSection(), "c"},
+		{`PrevInTag("go") of d`, posts[0].PrevInTag("go"), "a"},
+		{`NextInTag("go") of b`, posts[2].NextInTag("go"), "d"},
+	}
+
+	for _, test := range tests {
+		if got := title(test.got); got != test.expected {
+			t.Errorf("%s = %q, expected %q", test.name, got, test.expected)
+		}
+	}
+
+	standalone := post("pages", "about")
+	standalone.Posts = posts
+	if standalone.Prev() != nil || standalone.Next() != nil {
+		t.Errorf("standalone page has neighbours")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	long := strings.Repeat("word ", SummaryWords+10)
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"<h1>Title</h1>\n\n<p>First &amp; only.</p>\n\n<p>Second.</p>\n", "<p>First &amp; only.</p>"},
+		{"<p>" + long + "</p>\n", "<p>" + strings.TrimSpace(strings.Repeat("word ", SummaryWords)) + "…</p>"},
+		{"<ul><li>a <b>list</b></li></ul>\n", "<p>a list</p>"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		page := Page{Content: template.HTML(test.content)}
+		if summary := string(page.Summary()); summary != test.expected {
+			t.Errorf("Summary() of %q = %q, expected %q", test.content, summary, test.expected)
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := map[string]string{
+		"<p>written by <code>stationery init</code>.</p>":                      "written by stationery init.",
+		"<h1>Title</h1><p>a <em>b</em>, <a href=\"#c\">c</a>!</p>":             "Title a b, c!",
+		"<ul>\n<li>one</li><li>two</li>\n</ul><p>line<br />break &amp; co</p>": "one two line break & co",
+	}
+
+	for content, expected := range tests {
+		page := Page{Content: template.HTML(content)}
+		if text := page.Text(); text != expected {
+			t.Errorf("Text() of %q = %q, expected %q", content, text, expected)
+		}
+	}
+}
+
+func TestSummaryMore(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"First.\n\n<!--more-->\n\nRest.\n", "<p>First.</p>"},
+		{"# Title\n\nFirst.\n\n<!--more-->\n\n* rest\n", `<h1 id="title">Title</h1>` + "\n\n<p>First.</p>"},
+		// markers within other blocks don't cut, the summary is the first paragraph then
+		{"First <!--more--> rest.\n\nSecond.\n", "<p>First <!--more--> rest.</p>"},
+		{"> First.\n>\n> <!--more-->\n>\n> Rest.\n", "<p>First.</p>"},
+		// an indented marker ends the list before it
+		{"* item\n\n  <!--more-->\n\n* other\n", "<ul>\n<li>item</li>\n</ul>"},
+	}
+
+	for _, test := range tests {
+		page := Page{Raw: test.raw}
+		err := page.parseContent()
+		if err != nil {
+			t.Fatalf("unable to parse content: %v", err)
+		}
+		if summary := string(page.Summary()); summary != test.expected {
+			t.Errorf("Summary() of %q = %q, expected %q", test.raw, summary, test.expected)
+		}
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	tests := map[int]int{0: 0, 1: 1, WordsPerMinute: 1, WordsPerMinute + 1: 2}
+
+	for words, expected := range tests {
+		page := Page{Content: template.HTML("<p>" + strings.Repeat("word ", words) + "</p>")}
+		if page.WordCount() != words {
+			t.Errorf("WordCount() = %d, expected %d", page.WordCount(), words)
+		}
+		if page.ReadingTime() != expected {
+			t.Errorf("ReadingTime() of %d words = %d, expected %d", words, page.ReadingTime(), expected)
+		}
+	}
+}
+
+func TestTableOfContents(t *testing.T) {
+	page := Page{Raw: "# Intro\n\n[TOC]\n\n## Setup\n\n### Go `1.10`\n\n## Setup\n\n## Custom {#mine}\n\n# Intro\n"}
+	err := page.parseContent()
+	if err != nil {
+		t.Fatalf("unable to parse content: %v", err)
+	}
+
+	var ids []string
+	var walk func(headings []*Heading, depth int)
+	walk = func(headings []*Heading, depth int) {
+		for _, h := range headings {
+			ids = append(ids, fmt.Sprintf("%s%s", strings.Repeat(">", depth), h.ID))
+			walk(h.Children, depth+1)
+		}
+	}
+	walk(page.TableOfContents, 0)
+
+	expected := "intro >setup >>go-1-10 >setup-1 >mine intro-1"
+	if got := strings.Join(ids, " "); got != expected {
+		t.Errorf("TableOfContents = %q, expected %q", got, expected)
+	}
+
+	// the table of contents only goes in when the page is generated
+	if strings.Contains(string(page.Content), "<nav") {
+		t.Errorf("content = %q, expected no table of contents before generating", page.Content)
+	}
+	if page.WordCount() != 7 {
+		t.Errorf("WordCount() = %d, expected 7 without the table of contents", page.WordCount())
+	}
+
+	content := page.insertTOC(string(page.Content))
+	for _, html := range []string{
+		`<h1 id="intro">Intro</h1>`,
+		`<h2 id="setup-1">Setup</h2>`,
+		`<h1 id="intro-1">Intro</h1>`,
+		`<nav class="toc"><ul><li><a href="#intro">Intro</a><ul><li><a href="#setup">Setup</a><ul><li><a href="#go-1-10">Go 1.10</a></li></ul></li>`,
+	} {
+		if !strings.Contains(content, html) {
+			t.Errorf("content = %q, expected %s", content, html)
+		}
+	}
+	if strings.Contains(content, TOCMarker) {
+		t.Errorf("TOC marker wasn't replaced in %q", content)
+	}
+
+	page = Page{Raw: "# Intro\n"}
+	page.Data.TOC = true
+	err = page.parseContent()
+	if err != nil {
+		t.Fatalf("unable to parse content: %v", err)
+	}
+	if content := page.insertTOC(string(page.Content)); !strings.HasPrefix(content, `<nav class="toc">`) {
+		t.Errorf("`toc: true` didn't start the content with the table of contents: %q", content)
+	}
+	if summary := page.SummaryText(); summary != "Intro" {
+		t.Errorf("SummaryText() = %q, expected the table of contents left out", summary)
+	}
+}
+
+func TestHostileFrontMatter(t *testing.T) {
+	page := Page{Root: "https://example.com/", Destination: "out/zomg.html"}
+	page.Data.Title = `"><script>alert(1)</script>`
+	page.Data.Description = `it's "quoted" & <b>bold</b>`
+	page.Data.Twitter = `x" onload="alert(1)`
+	page.Data.Image = `img.png" onerror="alert(1)`
+	page.Data.Tags = []string{`<i>go</i>`, `" onclick="alert(1)`}
+	page.Data.Timestamp = "2018-03-24T10:00:00Z"
+	index := Page{Root: "https://example.com/", Children: []*Page{&page}}
+	menu := Page{Root: "https://example.com/", MenuPages: []*Page{&page}}
+
+	outputs := map[string]string{
+		"Headers": string(page.Headers()),
+		"Tags":    string(page.Tags()),
+		"Link":    string(page.Link()),
+		"Index":   string(index.Index()),
+		"Menu":    string(menu.Menu()),
+	}
+
+	for name, out := range outputs {
+		for _, unsafe := range []string{"<script>", "<b>", "<i>", `" onload=`, `" onerror=`, `" onclick=`} {
+			if strings.Contains(out, unsafe) {
+				t.Errorf("%s must escape %q, got:\n%s", name, unsafe, out)
+			}
+		}
+	}
+
+	for _, expected := range []string{
+		`<title>&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;</title>`,
+		`<meta name="description" content="it&#39;s &#34;quoted&#34; &amp; &lt;b&gt;bold&lt;/b&gt;" />`,
+		`<meta name="twitter:creator" content="@x&#34; onload=&#34;alert(1)" />`,
+		`<meta property="og:title" content="&#34;&gt;&lt;script&gt;alert(1)&lt;/script&gt;" />`,
+	} {
+		if !strings.Contains(outputs["Headers"], expected) {
+			t.Errorf("expected Headers to contain %q, got:\n%s", expected, outputs["Headers"])
+		}
+	}
+
+	expected := `#<a href="https://example.com/tag/%3ci%3ego%3c/i%3e.html">&lt;i&gt;go&lt;/i&gt;</a>`
+	if !strings.Contains(outputs["Tags"], expected) {
+		t.Errorf("expected Tags to contain %q, got:\n%s", expected, outputs["Tags"])
+	}
+}
@@ -0,0 +1,64 @@
+package page
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/aedipamoss/stationery/config"
+)
+
+var permalinkRegex = regexp.MustCompile(config.PermalinkTokenRegex)
+
+// Returns the permalink pattern for this page, or nothing to keep the default path.
+// Standalone pages only use a pattern given for their own section, never the default.
+func (page Page) permalink() string {
+	if pattern, ok := page.Permalinks[page.Section()]; ok && page.Section() != "" {
+		return pattern
+	}
+
+	if page.Standalone() {
+		return ""
+	}
+
+	return page.Permalinks[config.DefaultPermalink]
+}
+
+// Replace the tokens of a permalink pattern with values from this page.
+//
+// A pattern ending with a slash is a directory, written as its index.html,
+// and ".html" is added to patterns without any extension.
+func (page Page) expandPermalink(pattern string) string {
+	date := page.Date()
+	expanded := permalinkRegex.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch token {
+		case ":year":
+			return fmt.Sprintf("%04d", date.Year())
+		case ":month":
+			return fmt.Sprintf("%02d", date.Month())
+		case ":day":
+			return fmt.Sprintf("%02d", date.Day())
+		case ":slug":
+			return page.Slug()
+		case ":title":
+			return Slugify(page.Title())
+		case ":section":
+			return page.Section()
+		}
+		return token
+	})
+
+	dir := strings.HasSuffix(expanded, "/")
+	expanded = strings.TrimPrefix(path.Clean("/"+expanded), "/")
+
+	if dir {
+		return expanded + "/"
+	}
+
+	if path.Ext(expanded) == "" {
+		expanded += ".html"
+	}
+
+	return expanded
+}
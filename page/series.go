@@ -0,0 +1,121 @@
+package page
+
+import (
+	"html/template"
+)
+
+// Returns the posts right before and after this one, among the ones matching filter.
+// Posts are sorted newest first, so the previous post is the older one.
+func (page Page) neighbours(filter func(p *Page) bool) (prev *Page, next *Page) {
+	var posts []*Page
+	for _, p := range page.Posts {
+		if p.Source == page.Source || filter(p) {
+			posts = append(posts, p)
+		}
+	}
+
+	for i, p := range posts {
+		if p.Source != page.Source {
+			continue
+		}
+
+		if i+1 < len(posts) {
+			prev = page.linked(posts[i+1])
+		}
+		if i > 0 {
+			next = page.linked(posts[i-1])
+		}
+		break
+	}
+
+	return prev, next
+}
+
+func anyPost(p *Page) bool {
+	return true
+}
+
+func (page Page) inSection(p *Page) bool {
+	return p.Section() == page.Section()
+}
+
+// Returns a filter for the posts tagged with tag.
+func tagged(tag string) func(p *Page) bool {
+	return func(p *Page) bool {
+		return contains(p.Data.Tags, tag)
+	}
+}
+
+// Prev is a member function made available in the page template.
+// It's the post published before this one, or nil for the first post,
+// so you can write `{{ with .Prev }}<a href="{{ .Href }}">{{ .Title }}</a>{{ end }}`.
+func (page Page) Prev() *Page {
+	prev, _ := page.neighbours(anyPost)
+	return prev
+}
+
+// Next is the post published after this one, or nil for the latest post.
+func (page Page) Next() *Page {
+	_, next := page.neighbours(anyPost)
+	return next
+}
+
+// PrevInSection is the post published before this one in the same section.
+func (page Page) PrevInSection() *Page {
+	prev, _ := page.neighbours(page.inSection)
+	return prev
+}
+
+// NextInSection is the post published after this one in the same section.
+func (page Page) NextInSection() *Page {
+	_, next := page.neighbours(page.inSection)
+	return next
+}
+
+// PrevInTag is the post published before this one with tag, like `{{ .PrevInTag "go" }}`.
+func (page Page) PrevInTag(tag string) *Page {
+	prev, _ := page.neighbours(tagged(tag))
+	return prev
+}
+
+// NextInTag is the post published after this one with tag.
+func (page Page) NextInTag(tag string) *Page {
+	_, next := page.neighbours(tagged(tag))
+	return next
+}
+
+// Series is a member function made available in the page template.
+// It lists every part of the series this page belongs to in order, itself included.
+func (page Page) Series() []*Page {
+	var parts []*Page
+	for _, p := range page.SeriesPages {
+		parts = append(parts, page.linked(p))
+	}
+
+	return parts
+}
+
+// SeriesIndex builds the overview of the series this page belongs to,
+// linking to every other part of it. It's empty for pages outside of a series.
+func (page Page) SeriesIndex() template.HTML {
+	data := struct {
+		Name  string
+		Part  int
+		Total int
+		Parts []seriesPart
+	}{Name: page.Data.Series, Total: len(page.SeriesPages)}
+
+	for i, p := range page.SeriesPages {
+		current := p.Source == page.Source
+		if current {
+			data.Part = i + 1
+		}
+		data.Parts = append(data.Parts, seriesPart{
+			Href:    page.href(p.Path()),
+			Text:    p.Title(),
+			Current: current,
+		})
+	}
+
+	return markup(seriesMarkup, data)
+}
@@ -0,0 +1,152 @@
+package page
+
+import (
+	"html"
+	"html/template"
+	"math"
+	"regexp"
+	"strings"
+
+	blackfriday "gopkg.in/russross/blackfriday.v2"
+)
+
+// MoreMarker ends the summary of a page when it's on a line of its own, everything written before it is the summary.
+const MoreMarker = "<!--more-->"
+
+// Stands in for the MoreMarker while the content is executed as a template.
+const morePlaceholder = "STATIONERY-MORE-MARKER"
+
+// SummaryWords is the longest summary made from the beginning of a page without a MoreMarker.
+var SummaryWords = 70
+
+// DescriptionWords is the longest description made from the summary of a page.
+var DescriptionWords = 30
+
+// WordsPerMinute is the reading speed used by ReadingTime().
+var WordsPerMinute = 200
+
+// Matches the first paragraph of some HTML.
+var paragraphRegex = regexp.MustCompile(`(?s)<p>.*?</p>`)
+
+// Matches the tags of elements separating blocks of text, like paragraphs, list items and line breaks.
+var blockTagRegex = regexp.MustCompile(`(?i)</?(address|article|aside|blockquote|br|dd|div|dl|dt|figcaption|figure|footer|h[1-6]|header|hr|li|nav|ol|p|pre|section|table|tbody|td|tfoot|th|thead|tr|ul)\b[^>]*>`)
+
+// Returns HTML as plain text, without any markup or extra white-space.
+// Blocks are separated by a space, inline tags like <em> are dropped so the text around them stays together.
+func plain(content string) string {
+	text := blockTagRegex.ReplaceAllString(content, " ")
+	text = tagRegex.ReplaceAllString(text, "")
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// Returns the first n words of text, ending with "…" when some were left out.
+func truncate(text string, n int) string {
+	words := strings.Fields(text)
+	if len(words) <= n {
+		return text
+	}
+
+	return strings.Join(words[:n], " ") + "…"
+}
+
+// Reports whether node is a MoreMarker on its own, as an HTML block or as the only thing in a paragraph.
+func isMore(node *blackfriday.Node) bool {
+	if node.Type == blackfriday.HTMLBlock {
+		return strings.TrimSpace(string(node.Literal)) == MoreMarker
+	}
+	if node.Type != blackfriday.Paragraph {
+		return false
+	}
+
+	more := false
+	for child := node.FirstChild; child != nil; child = child.Next {
+		switch {
+		case child.Type == blackfriday.Text && strings.TrimSpace(string(child.Literal)) == "":
+		case child.Type == blackfriday.HTMLSpan && string(child.Literal) == MoreMarker && !more:
+			more = true
+		default:
+			return false
+		}
+	}
+
+	return more
+}
+
+// Cut ast at a MoreMarker on its own line, leaving only what's before it.
+// It returns false when there's none, markers within a paragraph, a list or a quote don't count.
+func cutAtMore(ast *blackfriday.Node) bool {
+	var more *blackfriday.Node
+	for node := ast.FirstChild; node != nil; node = node.Next {
+		if isMore(node) {
+			more = node
+			break
+		}
+	}
+	if more == nil {
+		return false
+	}
+
+	for more != nil {
+		next := more.Next
+		more.Unlink()
+		more = next
+	}
+
+	return true
+}
+
+// Summary is a member function made available in the page template.
+// It's the content up to MoreMarker when it's on a line of its own, or else the first paragraph,
+// cut to SummaryWords and without its markup when it's longer.
+func (page Page) Summary() template.HTML {
+	if page.SummaryContent != "" {
+		return page.SummaryContent
+	}
+
+	content := string(page.Content)
+	first := paragraphRegex.FindString(content)
+	if first == "" {
+		first = content
+	}
+
+	text := plain(first)
+	if text == "" {
+		return ""
+	}
+	if first != content && len(strings.Fields(text)) <= SummaryWords {
+		// nolint: gosec
+		return template.HTML(first)
+	}
+
+	// nolint: gosec
+	return template.HTML("<p>" + html.EscapeString(truncate(text, SummaryWords)) + "</p>")
+}
+
+// SummaryText is the Summary() as plain text.
+func (page Page) SummaryText() string {
+	return plain(string(page.Summary()))
+}
+
+// WordCount is how many words the content of the page has.
+func (page Page) WordCount() int {
+	return len(strings.Fields(page.Text()))
+}
+
+// ReadingTime is how many minutes it takes to read the page, rounded up.
+func (page Page) ReadingTime() int {
+	return int(math.Ceil(float64(page.WordCount()) / float64(WordsPerMinute)))
+}
+
+// Returns the description for the meta tags of the page:
+// its own, the beginning of its summary, or the one of the site.
+func (page Page) metaDescription() string {
+	if page.Data.Description != "" {
+		return page.Data.Description
+	}
+
+	if summary := page.SummaryText(); summary != "" {
+		return truncate(summary, DescriptionWords)
+	}
+
+	return page.Site.Description
+}
@@ -0,0 +1,122 @@
+package page
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/shurcooL/sanitized_anchor_name"
+	blackfriday "gopkg.in/russross/blackfriday.v2"
+)
+
+// TOCMarker is replaced by the table of contents when it's on a line of its own in the content.
+const TOCMarker = "[TOC]"
+
+// Keeps the place of the table of contents in the content until the page is generated,
+// so the summary and text of the page are without it.
+const tocPlaceholder = "<!--toc-->"
+
+// Heading is an entry of the table of contents of a page.
+type Heading struct {
+	Level    int    // from 1 for "#" to 6
+	ID       string // anchor of the heading in the page, unique within the page
+	Title    string // text of the heading, without its markup
+	Children []*Heading
+}
+
+// Href links to the heading from the page it's in.
+func (heading Heading) Href() string {
+	return "#" + heading.ID
+}
+
+// Returns the text of a node and its children, without markup.
+func nodeText(node *blackfriday.Node) string {
+	var buf bytes.Buffer
+	node.Walk(func(n *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && (n.Type == blackfriday.Text || n.Type == blackfriday.Code) {
+			buf.Write(n.Literal)
+		}
+		return blackfriday.GoToNext
+	})
+
+	return buf.String()
+}
+
+// Gives every heading of ast an ID no other heading of the page has,
+// then returns them nested by level.
+//
+// IDs come from `{#id}` after the heading or its text, like "getting-started",
+// a repeated ID gets a number after it, like "getting-started-1".
+func headings(ast *blackfriday.Node) []*Heading {
+	var toc []*Heading
+	var parents []*Heading
+	used := make(map[string]bool)
+
+	ast.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if !entering || node.Type != blackfriday.Heading {
+			return blackfriday.GoToNext
+		}
+
+		title := nodeText(node)
+		id := node.HeadingID
+		if id == "" {
+			id = sanitized_anchor_name.Create(title)
+		}
+		if id == "" {
+			id = "heading"
+		}
+		unique := id
+		for n := 1; used[unique]; n++ {
+			unique = fmt.Sprintf("%s-%d", id, n)
+		}
+		used[unique] = true
+		node.HeadingID = unique
+
+		heading := &Heading{Level: node.Level, ID: unique, Title: title}
+		for len(parents) > 0 && parents[len(parents)-1].Level >= heading.Level {
+			parents = parents[:len(parents)-1]
+		}
+		if len(parents) == 0 {
+			toc = append(toc, heading)
+		} else {
+			parent := parents[len(parents)-1]
+			parent.Children = append(parent.Children, heading)
+		}
+		parents = append(parents, heading)
+
+		return blackfriday.SkipChildren
+	})
+
+	return toc
+}
+
+// TOC is a member function made available in the page template.
+// It builds the table of contents of the page as nested lists of links to its headings.
+func (page Page) TOC() template.HTML {
+	return markup(tocMarkup, page.TableOfContents)
+}
+
+// Marks the place of the TOCMarker of rendered markdown, or removes it when keep is false.
+func markTOC(content string, keep bool) string {
+	placeholder := tocPlaceholder
+	if !keep {
+		placeholder = ""
+	}
+
+	return strings.Replace(content, "<p>"+TOCMarker+"</p>", placeholder, 1)
+}
+
+// Put the table of contents in place of the marked TOCMarker of content,
+// or at its beginning when the front-matter asks for it.
+func (page Page) insertTOC(content string) string {
+	if strings.Contains(content, tocPlaceholder) {
+		return strings.Replace(content, tocPlaceholder, string(page.TOC()), 1)
+	}
+
+	if page.Data.TOC {
+		return string(page.TOC()) + content
+	}
+
+	return content
+}
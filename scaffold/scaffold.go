@@ -0,0 +1,141 @@
+// Package scaffold creates the files needed for a new stationery project.
+package scaffold
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Options are the values used to fill in a new project.
+type Options struct {
+	Title   string
+	SiteURL string
+	Author  string
+	Email   string
+	Source  string
+	Output  string
+}
+
+// projectConfig mirrors the keys of config.Config we want in a fresh .station.yml.
+// It's a separate struct so the generated file only contains what was given.
+type projectConfig struct {
+	Title   string `yaml:"title,omitempty"`
+	SiteURL string `yaml:"site-url,omitempty"`
+	Name    string `yaml:"name,omitempty"`
+	Email   string `yaml:"email,omitempty"`
+	Source  string `yaml:"source"`
+	Output  string `yaml:"output"`
+	Assets  struct {
+		CSS []string `yaml:"css"`
+	} `yaml:"assets"`
+}
+
+// file is a single file to be written relative to the project directory.
+type file struct {
+	path    string
+	content string
+}
+
+func (opts Options) configFile() (string, error) {
+	cfg := projectConfig{
+		Title:   opts.Title,
+		SiteURL: opts.SiteURL,
+		Name:    opts.Author,
+		Email:   opts.Email,
+		Source:  opts.Source,
+		Output:  opts.Output,
+	}
+	cfg.Assets.CSS = []string{"style.css"}
+
+	out, err := yaml.Marshal(cfg)
+	return string(out), err
+}
+
+func (opts Options) examplePost(now time.Time) (string, error) {
+	data := struct {
+		Title     string   `yaml:"title"`
+		Timestamp string   `yaml:"timestamp"`
+		Tags      []string `yaml:"tags"`
+	}{
+		Title:     "Hello, world",
+		Timestamp: now.Format(time.RFC3339),
+		Tags:      []string{"stationery"},
+	}
+
+	out, err := yaml.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprint("---\n", string(out), "---\n\n", ExamplePost), nil
+}
+
+// files returns every file Init will create for the given options.
+func (opts Options) files(now time.Time) ([]file, error) {
+	cfg, err := opts.configFile()
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := opts.examplePost(now)
+	if err != nil {
+		return nil, err
+	}
+
+	return []file{
+		{".station.yml", cfg},
+		{filepath.Join(opts.Source, "hello-world.md"), post},
+		{filepath.Join("layouts", "page.html"), PageLayout},
+		{filepath.Join("layouts", "index.html"), IndexLayout},
+		{filepath.Join("assets", "css", "style.css"), Stylesheet},
+		{".gitignore", fmt.Sprintf("/%s/\n", filepath.ToSlash(opts.Output))},
+	}, nil
+}
+
+// Init writes a ready-to-build project into dir.
+//
+// No file is written if any of them already exists, so running it twice
+// or on top of an existing project is always safe.
+// It returns the list of files written.
+func Init(dir string, opts Options) ([]string, error) {
+	if opts.Source == "" {
+		opts.Source = "src"
+	}
+	if opts.Output == "" {
+		opts.Output = "out"
+	}
+
+	files, err := opts.files(time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		path := filepath.Join(dir, f.path)
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("refusing to overwrite existing file: %s", path)
+		}
+	}
+
+	var written []string
+	for _, f := range files {
+		path := filepath.Join(dir, f.path)
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return written, err
+		}
+
+		err = ioutil.WriteFile(path, []byte(f.content), 0644)
+		if err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
@@ -0,0 +1,68 @@
+package scaffold
+
+// PageLayout is the layout written to layouts/page.html for a new project.
+const PageLayout = `<!DOCTYPE html>
+<html>
+<head>
+{{ .Headers }}
+</head>
+<body>
+  <nav>
+    <a href="{{ .Home }}">home</a>
+    {{ .Menu }}
+  </nav>
+  <article>
+    {{ .SeriesIndex }}
+    {{ .Content }}
+    {{ .Tags }}
+  </article>
+  <nav class="pagination">
+    {{ with .Prev }}<a href="{{ .Href }}" rel="prev">{{ .Title }}</a>{{ end }}
+    {{ with .Next }}<a href="{{ .Href }}" rel="next">{{ .Title }}</a>{{ end }}
+  </nav>
+</body>
+</html>
+`
+
+// IndexLayout is the layout written to layouts/index.html for a new project.
+const IndexLayout = `<!DOCTYPE html>
+<html>
+<head>
+{{ .Headers }}
+</head>
+<body>
+  <nav>
+    {{ .Menu }}
+  </nav>
+  <h1>{{ .Title }}</h1>
+  <div id="index">
+    {{ .Index }}
+  </div>
+</body>
+</html>
+`
+
+// Stylesheet is the stylesheet written to assets/css/style.css for a new project.
+const Stylesheet = `html {
+    font-family: sans-serif;
+    line-height: 1.5;
+}
+
+body {
+    max-width: 40em;
+    margin: 0 auto;
+    padding: 1em;
+}
+
+.tag {
+    margin-right: 0.5em;
+}
+`
+
+// ExamplePost is the body of the example post written for a new project.
+const ExamplePost = `# Hello, world
+
+This is your first post, written by ` + "`stationery init`" + `.
+
+Edit or delete it, then run ` + "`stationery`" + ` to build your site.
+`
@@ -0,0 +1,76 @@
+// Package search builds an index of the site, searched from the browser without a backend.
+//
+// The index is a list of documents, one per page.
+// Inverted, it also maps the stem of every word to the documents it's in,
+// and drops their text to keep the file small. Stems come from the Porter algorithm, see Stem.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Document is a page of the index.
+type Document struct {
+	Title string   `json:"title"`
+	URL   string   `json:"url"`
+	Date  string   `json:"date,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	Text  string   `json:"text,omitempty"`
+}
+
+// Index is what's written to the search index of the site.
+type Index struct {
+	Documents []Document `json:"pages"`
+	// Terms maps stems to the position of the documents they are in, only when inverted
+	Terms map[string][]int `json:"index,omitempty"`
+}
+
+// Words splits text into lower-case words of letters and digits.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Invert maps the stem of every word from the title, tags and text of each document
+// to the documents it's found in, then drops the text of the documents.
+func (index *Index) Invert() {
+	index.Terms = make(map[string][]int)
+	for i, doc := range index.Documents {
+		text := doc.Title + " " + strings.Join(doc.Tags, " ") + " " + doc.Text
+		for _, word := range Words(text) {
+			stem := Stem(word)
+			docs := index.Terms[stem]
+			if len(docs) == 0 || docs[len(docs)-1] != i {
+				index.Terms[stem] = append(docs, i)
+			}
+		}
+		index.Documents[i].Text = ""
+	}
+}
+
+// Search returns the position of the documents containing every word of query, for an inverted index.
+// It's what a client of the index is expected to do, and mostly used to test it.
+func (index *Index) Search(query string) []int {
+	var found []int
+	for n, word := range Words(query) {
+		docs := index.Terms[Stem(word)]
+		if n == 0 {
+			found = append([]int(nil), docs...)
+			continue
+		}
+
+		var both []int
+		for _, doc := range found {
+			i := sort.SearchInts(docs, doc)
+			if i < len(docs) && docs[i] == doc {
+				both = append(both, doc)
+			}
+		}
+		found = both
+	}
+
+	return found
+}
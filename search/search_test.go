@@ -0,0 +1,38 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInvert(t *testing.T) {
+	index := Index{Documents: []Document{
+		{Title: "Generics in Go", Tags: []string{"go"}, Text: "Trying generic functions."},
+		{Title: "Testing", Tags: []string{"go", "tests"}, Text: "Running the tests, generically."},
+		{Title: "Cats", Text: "Nothing about Go."},
+	}}
+	index.Invert()
+
+	for _, doc := range index.Documents {
+		if doc.Text != "" {
+			t.Errorf("expected the text of %q to be dropped", doc.Title)
+		}
+	}
+
+	tests := map[string][]int{
+		"go":               {0, 1, 2},
+		"generic":          {0, 1},
+		"test":             {1},
+		"running tests":    {1},
+		"generics cats":    nil,
+		"missing":          nil,
+		"GENERIC, go!":     {0, 1},
+		"functions trying": {0},
+	}
+
+	for query, expected := range tests {
+		if got := index.Search(query); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Search(%q) = %v, expected %v", query, got, expected)
+		}
+	}
+}